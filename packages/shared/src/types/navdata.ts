@@ -195,18 +195,39 @@ export interface AirspaceBoundary {
 }
 
 /** Video map feature type */
-export type VideoMapFeatureType = 'line' | 'polygon' | 'label' | 'symbol';
+export type VideoMapFeatureType = 'line' | 'multiline' | 'arc' | 'polygon' | 'label' | 'symbol';
 
 /** A single feature within a video map (polyline, polygon, label, or airport symbol) */
 export interface VideoMapFeature {
+  /** Stable per-feature ID, "{mapId}-{stripIndex}" (vice-extract -feature-ids) */
+  id?: string;
   /** Feature type */
   type: VideoMapFeatureType;
   /** Coordinate pairs for lines/polygons */
   points?: Position[];
+  /** Every strip of a 'multiline' feature (vice-extract -multiline), in place of points */
+  lines?: Position[][];
+  /** True bearing of each segment, one per pair of points (vice-extract -include-bearings) */
+  bearings?: number[];
+  /** Distance in nm from the first point to each point along the line (vice-extract -include-cumulative-length) */
+  cumLength?: number[];
+  /** Circular arc geometry for 'arc' features (vice-extract -detect-arcs), in place of points */
+  arc?: {
+    center: Position;
+    radiusNm: number;
+    /** True bearing from center to the first point */
+    startBearing: number;
+    /** True bearing from center to the last point */
+    endBearing: number;
+    /** Direction of travel from start to end */
+    clockwise: boolean;
+  };
   /** Label text (for 'label' and 'symbol' types) */
   text?: string;
   /** Label/symbol position */
   position?: Position;
+  /** The map's restriction, repeated per feature (vice-extract -per-feature-restrictions) */
+  restriction?: { id: number; text: [string, string]; textBlink?: boolean; hideText?: boolean };
   /** Optional color override (hex) */
   color?: string;
   /** Line dash pattern [dash, gap] - solid if omitted */
@@ -229,10 +250,22 @@ export interface VideoMap {
   group?: number;
   /** Vice category index */
   category?: number;
+  /** Display name for group (from a -group-names table) */
+  groupName?: string;
+  /** Display name for category (from a -category-names table) */
+  categoryName?: string;
   /** Vice color index (0-8) */
   color?: number;
   /** Features in this map */
   features: VideoMapFeature[];
+  /** Placeholder with no features (e.g. clipped away); list it grayed out */
+  disabled?: boolean;
+  /** Length-weighted centroid of the map's lines, for label placement */
+  centroid?: Position;
+  /** Smallest circle enclosing the map's line points, for radial hit-testing */
+  boundingCircle?: { centerLat: number; centerLon: number; radiusNm: number };
+  /** Draw priority, higher on top (from a -z-order-file; unlisted maps use group) */
+  zOrder?: number;
 }
 
 /** Complete airport nav data */
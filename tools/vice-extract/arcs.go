@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Arc detection (-detect-arcs)
+// Vice stores every map line as a flattened polyline (VideoMap.Lines has
+// no arc metadata), so DME arcs and similar curves are recovered by
+// fitting a circle to each strip and accepting close fits.
+// ──────────────────────────────────────────────────────────────────────
+
+// ArcGeometry describes a circular arc feature (type "arc")
+type ArcGeometry struct {
+	Center       Position `json:"center"`
+	RadiusNM     float64  `json:"radiusNm"`
+	StartBearing float64  `json:"startBearing"` // true bearing from center to the first point
+	EndBearing   float64  `json:"endBearing"`   // true bearing from center to the last point
+	Clockwise    bool     `json:"clockwise"`    // direction of travel from start to end
+}
+
+const (
+	arcMinPoints   = 5
+	arcMinSweepDeg = 15.0 // shallower curves stay polylines
+)
+
+// fitArc fits a circle to an open strip (not isClosed within closeEpsNM)
+// and returns the arc it traces if every point lies within toleranceNM of
+// the circle and the points sweep monotonically through at least
+// arcMinSweepDeg.
+func fitArc(strip []Point2LL, toleranceNM, closeEpsNM float64) (ArcGeometry, bool) {
+	if len(strip) < arcMinPoints || isClosed(strip, closeEpsNM) {
+		return ArcGeometry{}, false
+	}
+
+	// Project to nm about the first point, then center on the mean for a
+	// well-conditioned fit
+	lon0, lat0 := float64(strip[0][0]), float64(strip[0][1])
+	kx, ky := nmPerDegLon(lat0), nmPerDegLat(lat0)
+	xs := make([]float64, len(strip))
+	ys := make([]float64, len(strip))
+	var mx, my float64
+	for i, p := range strip {
+		xs[i] = (float64(p[0]) - lon0) * kx
+		ys[i] = (float64(p[1]) - lat0) * ky
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(strip))
+	my /= float64(len(strip))
+
+	// Kåsa algebraic fit: x²+y²+Dx+Ey+F = 0, least squares in D, E, F
+	var sxx, sxy, syy, sx, sy, sxz, syz, sz float64
+	for i := range xs {
+		x, y := xs[i]-mx, ys[i]-my
+		z := x*x + y*y
+		sxx += x * x
+		sxy += x * y
+		syy += y * y
+		sx += x
+		sy += y
+		sxz += x * z
+		syz += y * z
+		sz += z
+	}
+	n := float64(len(xs))
+	D, E, F, ok := solve3(
+		[3][3]float64{{sxx, sxy, sx}, {sxy, syy, sy}, {sx, sy, n}},
+		[3]float64{-sxz, -syz, -sz},
+	)
+	if !ok {
+		return ArcGeometry{}, false // collinear
+	}
+	cx, cy := -D/2, -E/2
+	r2 := cx*cx + cy*cy - F
+	if r2 <= 0 {
+		return ArcGeometry{}, false
+	}
+	r := math.Sqrt(r2)
+
+	// Every point near the circle, sweeping one way
+	prevAngle := 0.0
+	sweep := 0.0
+	for i := range xs {
+		x, y := xs[i]-mx-cx, ys[i]-my-cy
+		if math.Abs(math.Hypot(x, y)-r) > toleranceNM {
+			return ArcGeometry{}, false
+		}
+		angle := math.Atan2(y, x)
+		if i > 0 {
+			d := math.Remainder(angle-prevAngle, 2*math.Pi)
+			if d == 0 || (sweep != 0 && (d > 0) != (sweep > 0)) {
+				return ArcGeometry{}, false
+			}
+			sweep += d
+		}
+		prevAngle = angle
+	}
+	sweepDeg := math.Abs(sweep) * 180 / math.Pi
+	if sweepDeg < arcMinSweepDeg || sweepDeg >= 360 {
+		return ArcGeometry{}, false
+	}
+
+	center := Position{
+		Lat: lat0 + (cy+my)/ky,
+		Lon: lon0 + (cx+mx)/kx,
+	}
+	first := Position{Lat: float64(strip[0][1]), Lon: float64(strip[0][0])}
+	last := Position{Lat: float64(strip[len(strip)-1][1]), Lon: float64(strip[len(strip)-1][0])}
+	return ArcGeometry{
+		Center:       center,
+		RadiusNM:     r,
+		StartBearing: bearingDeg(center, first),
+		EndBearing:   bearingDeg(center, last),
+		Clockwise:    sweep < 0, // math angles run counter-clockwise
+	}, true
+}
+
+// solve3 solves a 3x3 linear system by Cramer's rule
+func solve3(a [3][3]float64, b [3]float64) (float64, float64, float64, bool) {
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det(a)
+	if math.Abs(d) < 1e-12 {
+		return 0, 0, 0, false
+	}
+	var out [3]float64
+	for col := 0; col < 3; col++ {
+		m := a
+		for row := 0; row < 3; row++ {
+			m[row][col] = b[row]
+		}
+		out[col] = det(m) / d
+	}
+	return out[0], out[1], out[2], true
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitArc(t *testing.T) {
+	// 10 nm DME arc, clockwise from 000 to 090, around 37N 77W
+	center := Position{Lat: 37, Lon: -77}
+	var arc []Point2LL
+	for brg := 0.0; brg <= 90; brg += 10 {
+		rad := brg * math.Pi / 180
+		arc = append(arc, Point2LL{
+			float32(center.Lon + 10*math.Sin(rad)/nmPerDegLon(center.Lat)),
+			float32(center.Lat + 10*math.Cos(rad)/nmPerDegLat(center.Lat)),
+		})
+	}
+
+	got, ok := fitArc(arc, 0.05, 0)
+	if !ok {
+		t.Fatal("10 nm arc not detected")
+	}
+	if math.Abs(got.RadiusNM-10) > 0.05 {
+		t.Errorf("RadiusNM = %.3f, want 10", got.RadiusNM)
+	}
+	if distanceNM(got.Center.Lat, got.Center.Lon, center.Lat, center.Lon) > 0.05 {
+		t.Errorf("Center = %+v, want %+v", got.Center, center)
+	}
+	if math.Abs(got.StartBearing-0) > 0.5 && math.Abs(got.StartBearing-360) > 0.5 {
+		t.Errorf("StartBearing = %.1f, want 0", got.StartBearing)
+	}
+	if math.Abs(got.EndBearing-90) > 0.5 {
+		t.Errorf("EndBearing = %.1f, want 90", got.EndBearing)
+	}
+	if !got.Clockwise {
+		t.Error("Clockwise = false, want true")
+	}
+
+	line := []Point2LL{{-77, 37}, {-77, 37.01}, {-77, 37.02}, {-77, 37.03}, {-77, 37.04}}
+	if _, ok := fitArc(line, 0.05, 0); ok {
+		t.Error("straight line detected as an arc")
+	}
+	zigzag := []Point2LL{{-77, 37}, {-76.99, 37.01}, {-77, 37.02}, {-76.99, 37.03}, {-77, 37.04}}
+	if _, ok := fitArc(zigzag, 0.05, 0); ok {
+		t.Error("zigzag detected as an arc")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Minimal enclosing circle per map (-include-bounding-circle)
+// Welzl's algorithm (iterative form) over the emitted line points, in the
+// flat nm projection centered on the points' bounding box. The points are
+// shuffled with a fixed seed for expected linear time; the result doesn't
+// depend on the order. Arc and label features are not included.
+// ──────────────────────────────────────────────────────────────────────
+
+// BoundingCircle is the smallest circle enclosing a map's points
+type BoundingCircle struct {
+	CenterLat float64 `json:"centerLat"`
+	CenterLon float64 `json:"centerLon"`
+	RadiusNM  float64 `json:"radiusNm"`
+}
+
+// circleXY is a circle in the flat nm projection
+type circleXY struct{ x, y, r float64 }
+
+// circleEpsilonNM absorbs floating-point error in containment tests
+const circleEpsilonNM = 1e-9
+
+func (c circleXY) contains(p [2]float64) bool {
+	return math.Hypot(p[0]-c.x, p[1]-c.y) <= c.r+circleEpsilonNM
+}
+
+// boundingCircle returns the minimal enclosing circle of the features'
+// points, or false if they have none
+func boundingCircle(features []VideoMapFeature) (BoundingCircle, bool) {
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	n := 0
+	for _, f := range features {
+		for _, p := range f.Points {
+			minLat, maxLat = min(minLat, p.Lat), max(maxLat, p.Lat)
+			minLon, maxLon = min(minLon, p.Lon), max(maxLon, p.Lon)
+			n++
+		}
+	}
+	if n == 0 {
+		return BoundingCircle{}, false
+	}
+
+	originLat, originLon := (minLat+maxLat)/2, (minLon+maxLon)/2
+	kx, ky := nmPerDegLon(originLat), nmPerDegLat(originLat)
+	pts := make([][2]float64, 0, n)
+	for _, f := range features {
+		for _, p := range f.Points {
+			pts = append(pts, [2]float64{(p.Lon - originLon) * kx, (p.Lat - originLat) * ky})
+		}
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	rng.Shuffle(len(pts), func(i, j int) { pts[i], pts[j] = pts[j], pts[i] })
+
+	c := welzl(pts)
+	return BoundingCircle{
+		CenterLat: originLat + c.y/ky,
+		CenterLon: originLon + c.x/kx,
+		RadiusNM:  c.r,
+	}, true
+}
+
+// welzl is the iterative Welzl algorithm: grow the circle whenever a point
+// falls outside it, re-solving with that point (and then a second) on the
+// boundary
+func welzl(pts [][2]float64) circleXY {
+	c := circleXY{pts[0][0], pts[0][1], 0}
+	for i := 1; i < len(pts); i++ {
+		if c.contains(pts[i]) {
+			continue
+		}
+		c = circleXY{pts[i][0], pts[i][1], 0}
+		for j := 0; j < i; j++ {
+			if c.contains(pts[j]) {
+				continue
+			}
+			c = circleFrom2(pts[i], pts[j])
+			for k := 0; k < j; k++ {
+				if !c.contains(pts[k]) {
+					c = circleFrom3(pts[i], pts[j], pts[k])
+				}
+			}
+		}
+	}
+	return c
+}
+
+// circleFrom2 is the circle with a and b as a diameter
+func circleFrom2(a, b [2]float64) circleXY {
+	return circleXY{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2, math.Hypot(a[0]-b[0], a[1]-b[1]) / 2}
+}
+
+// circleFrom3 is the circumcircle of a, b, c. For (nearly) collinear
+// points it falls back to the widest of the three diameter circles.
+func circleFrom3(a, b, c [2]float64) circleXY {
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	d := 2 * (bx*cy - by*cx)
+	if math.Abs(d) < 1e-12 {
+		best := circleFrom2(a, b)
+		for _, cand := range []circleXY{circleFrom2(a, c), circleFrom2(b, c)} {
+			if cand.r > best.r {
+				best = cand
+			}
+		}
+		return best
+	}
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	ux := (cy*b2 - by*c2) / d
+	uy := (bx*c2 - cx*b2) / d
+	return circleXY{a[0] + ux, a[1] + uy, math.Hypot(ux, uy)}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundingCircleSquare(t *testing.T) {
+	// A ~10 nm square at the equator (1° lon ≈ 60 nm there): the circle
+	// passes through the corners, radius half the diagonal
+	side := 10.0 / 60
+	square := []Position{
+		{Lat: 0, Lon: 0}, {Lat: 0, Lon: side}, {Lat: side, Lon: side}, {Lat: side, Lon: 0}, {Lat: 0, Lon: 0},
+	}
+	// Interior points must not move it
+	inner := []Position{{Lat: side / 2, Lon: side / 2}, {Lat: side / 4, Lon: side / 3}}
+	got, ok := boundingCircle([]VideoMapFeature{{Type: "line", Points: square}, {Type: "line", Points: inner}})
+	if !ok {
+		t.Fatal("want a circle")
+	}
+	if want := 5 * math.Sqrt2; math.Abs(got.RadiusNM-want) > 1e-4 {
+		t.Errorf("radius = %v nm, want %v", got.RadiusNM, want)
+	}
+	if math.Abs(got.CenterLat-side/2) > 1e-9 || math.Abs(got.CenterLon-side/2) > 1e-9 {
+		t.Errorf("center = (%v, %v), want (%v, %v)", got.CenterLat, got.CenterLon, side/2, side/2)
+	}
+
+	if _, ok := boundingCircle([]VideoMapFeature{{Type: "arc"}}); ok {
+		t.Error("no points: want ok = false")
+	}
+}
+
+func TestWelzlTriangle(t *testing.T) {
+	// Obtuse triangle: the circle is the longest side's diameter, not the
+	// circumcircle
+	pts := [][2]float64{{0, 0}, {10, 0}, {5, 1}}
+	c := welzl(pts)
+	if math.Abs(c.x-5) > 1e-9 || math.Abs(c.y) > 1e-9 || math.Abs(c.r-5) > 1e-9 {
+		t.Errorf("circle = %+v, want {5 0 5}", c)
+	}
+}
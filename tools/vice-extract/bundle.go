@@ -0,0 +1,113 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Single-archive input (-bundle)
+// A zip or tar (optionally gzipped) holding e.g. ZDC-videomaps.gob.zst and
+// ZDC-manifest.gob. Entries are recognized by name:
+//   manifest:  *manifest.gob
+//   videomaps: *.gob.zst, or any other *.gob
+// ──────────────────────────────────────────────────────────────────────
+
+type bundleContents struct {
+	VideoMapsName string
+	VideoMaps     []byte
+	ManifestName  string // empty if the bundle has no manifest
+	Manifest      []byte
+}
+
+func readBundle(bundlePath string) (*bundleContents, error) {
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bundleContents
+	add := func(name string, open func() ([]byte, error)) error {
+		base := strings.ToLower(path.Base(name))
+		var dstName *string
+		var dst *[]byte
+		switch {
+		case strings.HasSuffix(base, "manifest.gob"):
+			dstName, dst = &b.ManifestName, &b.Manifest
+		case strings.HasSuffix(base, ".gob.zst"), strings.HasSuffix(base, ".gob"):
+			dstName, dst = &b.VideoMapsName, &b.VideoMaps
+		default:
+			return nil
+		}
+		if *dstName != "" {
+			return fmt.Errorf("bundle has more than one candidate: %s and %s", *dstName, name)
+		}
+		content, err := open()
+		if err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+		*dstName, *dst = name, content
+		return nil
+	}
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("open zip: %w", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			err := add(f.Name, func() ([]byte, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		var r io.Reader = bytes.NewReader(data)
+		if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+			gr, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, fmt.Errorf("open tar.gz: %w", err)
+			}
+			defer gr.Close()
+			r = gr
+		}
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read tar (bundle must be zip or tar): %w", err)
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := add(hdr.Name, func() ([]byte, error) { return io.ReadAll(tr) }); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if b.VideoMapsName == "" {
+		return nil, fmt.Errorf("no videomaps entry (*.gob.zst or *.gob) found in %s", bundlePath)
+	}
+	return &b, nil
+}
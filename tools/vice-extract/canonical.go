@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Canonical JSON (-canonical)
+// Object keys sorted, numbers written with strconv 'f' formatting (never
+// an exponent), integers as integers, and fixed whitespace. The bytes
+// depend only on the data, not on struct field order or encoding/json's
+// float heuristics, so equal outputs hash equal.
+// ──────────────────────────────────────────────────────────────────────
+
+// canonicalJSON encodes v canonically, compactly or with two-space indent
+func canonicalJSON(v any, compact bool) ([]byte, error) {
+	// Round-trip through encoding/json so struct tags and omitempty apply
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal JSON: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := writeCanonical(&b, generic, compact, 0); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeCanonical(b *bytes.Buffer, v any, compact bool, depth int) error {
+	newline := func(d int) {
+		if !compact {
+			b.WriteByte('\n')
+			b.WriteString(strings.Repeat("  ", d))
+		}
+	}
+	switch x := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(x))
+	case string:
+		s, err := json.Marshal(x)
+		if err != nil {
+			return err
+		}
+		b.Write(s)
+	case json.Number:
+		if _, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+			b.WriteString(string(x))
+			break
+		}
+		f, err := strconv.ParseFloat(string(x), 64)
+		if err != nil {
+			return fmt.Errorf("canonical JSON: bad number %q", x)
+		}
+		b.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	case []any:
+		if len(x) == 0 {
+			b.WriteString("[]")
+			break
+		}
+		b.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeCanonical(b, e, compact, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte(']')
+	case map[string]any:
+		if len(x) == 0 {
+			b.WriteString("{}")
+			break
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeCanonical(b, k, compact, depth+1); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if !compact {
+				b.WriteByte(' ')
+			}
+			if err := writeCanonical(b, x[k], compact, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		b.WriteByte('}')
+	default:
+		return fmt.Errorf("canonical JSON: unexpected %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	v := struct {
+		Zeta  float64        `json:"zeta"`
+		Alpha []any          `json:"alpha"`
+		Mid   map[string]int `json:"mid"`
+	}{
+		Zeta:  0.0000001, // encoding/json writes 1e-7
+		Alpha: []any{1e21, 37.5, 3, "a<b", nil, true},
+		Mid:   map[string]int{"b": 2, "a": 1},
+	}
+
+	got, err := canonicalJSON(v, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"alpha":[1000000000000000000000,37.5,3,"a\u003cb",null,true],"mid":{"a":1,"b":2},"zeta":0.0000001}`
+	if string(got) != want {
+		t.Errorf("compact:\ngot  %s\nwant %s", got, want)
+	}
+
+	indented, err := canonicalJSON(v, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIndented := "{\n  \"alpha\": [\n    1000000000000000000000,\n    37.5,\n    3,\n    \"a\\u003cb\",\n    null,\n    true\n  ],\n" +
+		"  \"mid\": {\n    \"a\": 1,\n    \"b\": 2\n  },\n  \"zeta\": 0.0000001\n}"
+	if string(indented) != wantIndented {
+		t.Errorf("indented:\ngot  %s\nwant %s", indented, wantIndented)
+	}
+
+	// Identical bytes across runs (map iteration order varies)
+	for i := 0; i < 20; i++ {
+		again, _ := canonicalJSON(v, true)
+		if !bytes.Equal(again, got) {
+			t.Fatalf("run %d differs:\n%s\n%s", i, again, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import "math"
+
+// ──────────────────────────────────────────────────────────────────────
+// Clip boundary preview (-emit-clip-outline)
+// A synthetic map drawing each clip region as a closed polygon, so a
+// reviewer can see what clipping kept. Clip regions are circles tested
+// with distanceNM, so the outline is traced with the same flat-earth
+// distance rather than a great circle: every vertex is exactly RadiusNM
+// from the center as clipping measures it.
+// ──────────────────────────────────────────────────────────────────────
+
+// clipOutlineID is the synthetic map's ID; no slugified Vice name can
+// collide with it unless a map is actually called "Clip Boundary"
+const clipOutlineID = "clip-boundary"
+
+// clipOutlineStepDeg is the bearing step between outline vertices
+const clipOutlineStepDeg = 5
+
+// clipOutlineMap returns a map with one closed line per region
+func clipOutlineMap(regions []clipRegion, precision int) OutputVideoMap {
+	features := make([]VideoMapFeature, 0, len(regions))
+	for _, r := range regions {
+		points := make([]Position, 0, 360/clipOutlineStepDeg+1)
+		for b := 0; b < 360; b += clipOutlineStepDeg {
+			rad := float64(b) * math.Pi / 180
+			lat := r.Lat + r.RadiusNM*math.Cos(rad)/nmPerDegLat(r.Lat)
+			lon := r.Lon + r.RadiusNM*math.Sin(rad)/nmPerDegLon((r.Lat+lat)/2)
+			points = append(points, Position{Lat: roundCoord(lat, precision), Lon: roundCoord(lon, precision)})
+		}
+		points = append(points, points[0])
+		features = append(features, VideoMapFeature{Type: "line", Points: points})
+	}
+	return OutputVideoMap{
+		ID:             clipOutlineID,
+		Name:           "Clip Boundary",
+		ShortName:      "CLIP",
+		DefaultVisible: true,
+		Features:       features,
+	}
+}
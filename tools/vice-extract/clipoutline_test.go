@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClipOutlineMap(t *testing.T) {
+	regions := []clipRegion{{Lat: 37.5, Lon: -77.3, RadiusNM: 40}, {Lat: 45, Lon: -77, RadiusNM: 10}}
+	m := clipOutlineMap(regions, 6)
+
+	if m.ID != clipOutlineID || len(m.Features) != 2 {
+		t.Fatalf("got id %q with %d features, want %q with 2", m.ID, len(m.Features), clipOutlineID)
+	}
+	for i, f := range m.Features {
+		r := regions[i]
+		if len(f.Points) != 360/clipOutlineStepDeg+1 || f.Points[0] != f.Points[len(f.Points)-1] {
+			t.Errorf("region %d: %d points, closed=%v; want a closed ring", i, len(f.Points), f.Points[0] == f.Points[len(f.Points)-1])
+		}
+		for _, p := range f.Points {
+			if d := distanceNM(r.Lat, r.Lon, p.Lat, p.Lon); math.Abs(d-r.RadiusNM) > 0.01 {
+				t.Errorf("region %d: vertex %+v is %.4f nm from the center, want %g", i, p, d, r.RadiusNM)
+				break
+			}
+		}
+	}
+}
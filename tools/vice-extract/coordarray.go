@@ -0,0 +1,43 @@
+package main
+
+// ──────────────────────────────────────────────────────────────────────
+// Array coordinates (-coord-format array)
+// Features carry "coordinates": [[lat, lon], ...] instead of "points":
+// [{lat, lon}, ...]. Under -vice-coord-order the pairs keep Vice's own
+// order instead: Point2LL[0] is LONGITUDE and Point2LL[1] latitude, so
+// pairs read [lon, lat] (also GeoJSON's order). The -wrap metadata
+// records which order was used. Arc and label features keep their
+// lat/lon objects.
+// ──────────────────────────────────────────────────────────────────────
+
+// Pair orders for -coord-format array (OutputMetadata.CoordOrder)
+const (
+	coordOrderLatLon = "latlon" // [lat, lon], matching Position field order
+	coordOrderLonLat = "lonlat" // [lon, lat], Vice's Point2LL order
+)
+
+// arrayMaps returns copies of maps with each feature's Points replaced by
+// Coordinates pairs in the given order
+func arrayMaps(maps []OutputVideoMap, order string) []OutputVideoMap {
+	out := make([]OutputVideoMap, len(maps))
+	for i, m := range maps {
+		features := make([]VideoMapFeature, len(m.Features))
+		for j, f := range m.Features {
+			if len(f.Points) > 0 {
+				f.Coordinates = make([][2]float64, len(f.Points))
+				for k, p := range f.Points {
+					if order == coordOrderLonLat {
+						f.Coordinates[k] = [2]float64{p.Lon, p.Lat}
+					} else {
+						f.Coordinates[k] = [2]float64{p.Lat, p.Lon}
+					}
+				}
+				f.Points = nil
+			}
+			features[j] = f
+		}
+		m.Features = features
+		out[i] = m
+	}
+	return out
+}
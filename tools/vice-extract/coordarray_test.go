@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestArrayMaps(t *testing.T) {
+	maps := []OutputVideoMap{{ID: "m", Features: []VideoMapFeature{
+		{Type: "line", Points: []Position{{Lat: 37.5, Lon: -77.3}, {Lat: 38, Lon: -76}}},
+		{Type: "label", Text: "X", Position: &Position{Lat: 1, Lon: 2}},
+	}}}
+
+	latlon := arrayMaps(maps, coordOrderLatLon)[0].Features
+	if got := latlon[0].Coordinates; len(got) != 2 || got[0] != [2]float64{37.5, -77.3} || latlon[0].Points != nil {
+		t.Errorf("latlon: coordinates %v, points %v", got, latlon[0].Points)
+	}
+	lonlat := arrayMaps(maps, coordOrderLonLat)[0].Features
+	if got := lonlat[0].Coordinates; got[0] != [2]float64{-77.3, 37.5} || got[1] != [2]float64{-76, 38} {
+		t.Errorf("lonlat: coordinates %v, want Vice [lon, lat] order", got)
+	}
+	if lonlat[1].Coordinates != nil || lonlat[1].Position == nil {
+		t.Error("label feature should keep its position object")
+	}
+	if maps[0].Features[0].Points == nil {
+		t.Error("arrayMaps modified its input")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// ──────────────────────────────────────────────────────────────────────
+// Whole-map deduplication (-dedupe-maps)
+// Merged inputs can carry the same map twice under different IDs. A map
+// is a duplicate when its features (hashed as in -since) and its name,
+// short name, group, category, and color all match an earlier map; the
+// first is kept. Visibility and IDs are not compared.
+// ──────────────────────────────────────────────────────────────────────
+
+// mapDuplicate is a dropped map and the earlier map it duplicated
+type mapDuplicate struct {
+	Index, KeptIndex int // into the maps passed to dedupeMaps
+}
+
+// dedupeKey is what two maps must share to be duplicates
+type dedupeKey struct {
+	Name, ShortName        string
+	Group, Category, Color int
+	Features               [32]byte
+}
+
+// dedupeMaps returns the indices of the maps to keep, in order, and the
+// duplicates dropped
+func dedupeMaps(maps []OutputVideoMap) ([]int, []mapDuplicate, error) {
+	first := make(map[dedupeKey]int, len(maps))
+	var kept []int
+	var dups []mapDuplicate
+	for i, m := range maps {
+		h, err := featuresHash(m.Features)
+		if err != nil {
+			return nil, nil, fmt.Errorf("map %s: %w", m.ID, err)
+		}
+		key := dedupeKey{m.Name, m.ShortName, m.Group, m.Category, m.Color, h}
+		if j, ok := first[key]; ok {
+			dups = append(dups, mapDuplicate{Index: i, KeptIndex: j})
+			continue
+		}
+		first[key] = i
+		kept = append(kept, i)
+	}
+	return kept, dups, nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDedupeMaps(t *testing.T) {
+	line := []VideoMapFeature{{Type: "line", Points: []Position{{Lat: 37, Lon: -77}, {Lat: 37, Lon: -76}}}}
+	other := []VideoMapFeature{{Type: "line", Points: []Position{{Lat: 38, Lon: -77}, {Lat: 38, Lon: -76}}}}
+	maps := []OutputVideoMap{
+		{ID: "1", Name: "A", ShortName: "A", Color: 1, Features: line},
+		{ID: "2", Name: "A", ShortName: "A", Color: 1, Features: line, DefaultVisible: true}, // dup of 1: visibility ignored
+		{ID: "3", Name: "A", ShortName: "A", Color: 2, Features: line},                       // color differs
+		{ID: "4", Name: "A", ShortName: "A", Color: 1, Features: other},                      // geometry differs
+		{ID: "5", Name: "A", ShortName: "A", Color: 1, Features: slices.Clone(line)},         // dup of 1
+	}
+
+	kept, dups, err := dedupeMaps(maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{0, 2, 3}; !slices.Equal(kept, want) {
+		t.Errorf("kept = %v, want %v", kept, want)
+	}
+	want := []mapDuplicate{{Index: 1, KeptIndex: 0}, {Index: 4, KeptIndex: 0}}
+	if !slices.Equal(dups, want) {
+		t.Errorf("dups = %v, want %v", dups, want)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import "math"
+
+// ──────────────────────────────────────────────────────────────────────
+// Earth model (-earth-model)
+// nmPerDegLat/nmPerDegLon drive every distance and flat projection
+// (clipping, simplification, lengths, centroids). The default sphere
+// uses 60 nm per degree of latitude and a cosine for longitude: fast, and
+// within about 0.5% at mid-latitudes. "wgs84" uses the ellipsoid's
+// latitude-dependent meridian and parallel lengths instead, which stays
+// accurate for high-latitude facilities.
+// ──────────────────────────────────────────────────────────────────────
+
+// Earth models for -earth-model
+const (
+	earthModelSphere = "sphere"
+	earthModelWGS84  = "wgs84"
+)
+
+// earthModel is the model nmPerDegLat/nmPerDegLon use
+var earthModel = earthModelSphere
+
+// WGS84 ellipsoid
+const (
+	wgs84SemiMajorM = 6378137.0
+	wgs84E2         = 6.69437999014e-3 // first eccentricity squared
+	metersPerNM     = 1852.0
+)
+
+// wgs84MeridianNMPerDeg is the length of one degree of latitude at lat:
+// the meridional radius of curvature M(φ) = a(1-e²)/(1-e²sin²φ)^1.5
+func wgs84MeridianNMPerDeg(lat float64) float64 {
+	s := math.Sin(lat * math.Pi / 180)
+	m := wgs84SemiMajorM * (1 - wgs84E2) / math.Pow(1-wgs84E2*s*s, 1.5)
+	return m * math.Pi / 180 / metersPerNM
+}
+
+// wgs84ParallelNMPerDeg is the length of one degree of longitude at lat:
+// the parallel's radius N(φ)cos φ, with N(φ) = a/√(1-e²sin²φ)
+func wgs84ParallelNMPerDeg(lat float64) float64 {
+	rad := lat * math.Pi / 180
+	s := math.Sin(rad)
+	n := wgs84SemiMajorM / math.Sqrt(1-wgs84E2*s*s)
+	return n * math.Cos(rad) * math.Pi / 180 / metersPerNM
+}
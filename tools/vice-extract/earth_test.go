@@ -0,0 +1,47 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEarthModelWGS84(t *testing.T) {
+	defer func() { earthModel = earthModelSphere }()
+
+	// Tabulated WGS84 degree lengths in meters (for the degree centered
+	// on lat, so the point values differ by about a meter)
+	for _, tc := range []struct {
+		lat, latM, lonM float64
+	}{
+		{0, 110574.3, 111319.5},
+		{45, 111132.9, 78846.8},
+		{80, 111661.0, 19393.9},
+	} {
+		earthModel = earthModelWGS84
+		if got := nmPerDegLat(tc.lat) * metersPerNM; math.Abs(got-tc.latM) > 2 {
+			t.Errorf("wgs84 lat degree at %g: %.1f m, want %.1f", tc.lat, got, tc.latM)
+		}
+		if got := nmPerDegLon(tc.lat) * metersPerNM; math.Abs(got-tc.lonM) > 2 {
+			t.Errorf("wgs84 lon degree at %g: %.1f m, want %.1f", tc.lat, got, tc.lonM)
+		}
+	}
+
+	// Compare the models over the same 1°×1° step: close mid-latitude,
+	// apart near the pole
+	dist := func(model string, lat float64) float64 {
+		earthModel = model
+		return distanceNM(lat, 0, lat+1, 1)
+	}
+	for _, tc := range []struct {
+		lat, maxDiff, minDiff float64
+	}{
+		{37, 0.5, 0},
+		{79, 2, 0.1},
+	} {
+		sphere, wgs84 := dist(earthModelSphere, tc.lat), dist(earthModelWGS84, tc.lat)
+		if d := math.Abs(sphere - wgs84); d > tc.maxDiff || d < tc.minDiff {
+			t.Errorf("at %g°: sphere %.3f nm, wgs84 %.3f nm (diff %.3f, want %g-%g)",
+				tc.lat, sphere, wgs84, d, tc.minDiff, tc.maxDiff)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/maps.gob":
+			w.Write([]byte("gob bytes"))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("late"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	data, err := fetchURL(srv.URL+"/maps.gob", time.Second)
+	if err != nil || string(data) != "gob bytes" {
+		t.Errorf("200: got %q, %v", data, err)
+	}
+	if _, err := fetchURL(srv.URL+"/missing", time.Second); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("404: err = %v, want the status", err)
+	}
+	if _, err := fetchURL(srv.URL+"/slow", 20*time.Millisecond); err == nil {
+		t.Error("slow response: expected a timeout")
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Fixed-decimal coordinates (-no-scientific, -compact-numbers)
+// encoding/json writes floats below 1e-6 in exponent form (1e-7), which
+// some naive parsers reject. With -no-scientific, renderMaps stamps a
+// positionFormat on every Position of the converted maps (points, lines,
+// label positions, centroids, arc centers), and Position.MarshalJSON
+// writes them with strconv 'f' formatting at the output's precision, so
+// coordinates are always plain decimals. -merge-into base maps are
+// copied through as-is, and array and localnm coordinates aren't
+// Positions, so they keep their formatting.
+//
+// Fixed decimals pad with zeros (37.50000); -compact-numbers trims them
+// again (37.5) without reintroducing exponents. encoding/json's own
+// formatting is already shortest, so it only applies to -no-scientific.
+// ──────────────────────────────────────────────────────────────────────
+
+// positionFormat is how Position.MarshalJSON writes coordinates; the zero
+// value leaves them to encoding/json
+type positionFormat struct {
+	fixed    bool // strconv 'f' at decimals places
+	decimals int
+	trim     bool // drop insignificant trailing zeros
+}
+
+// MarshalJSON writes p as {"lat":..,"lon":..} per its format
+func (p Position) MarshalJSON() ([]byte, error) {
+	if !p.format.fixed {
+		type plain struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		}
+		return json.Marshal(plain{p.Lat, p.Lon})
+	}
+	b := append([]byte(nil), `{"lat":`...)
+	b = p.format.append(b, p.Lat)
+	b = append(b, `,"lon":`...)
+	b = p.format.append(b, p.Lon)
+	return append(b, '}'), nil
+}
+
+// append appends v in fixed-decimal form
+func (pf positionFormat) append(b []byte, v float64) []byte {
+	start := len(b)
+	b = strconv.AppendFloat(b, v, 'f', pf.decimals, 64)
+	if !pf.trim || bytes.IndexByte(b[start:], '.') < 0 {
+		return b
+	}
+	num := bytes.TrimSuffix(bytes.TrimRight(b[start:], "0"), []byte("."))
+	if string(num) == "-0" {
+		num = num[1:]
+	}
+	return append(b[:start], num...)
+}
+
+// formatMaps returns copies of maps with pf set on every Position
+func formatMaps(maps []OutputVideoMap, pf positionFormat) []OutputVideoMap {
+	out := make([]OutputVideoMap, len(maps))
+	for i, m := range maps {
+		m.Features = formatFeatures(m.Features, pf)
+		m.Centroid = formatPosition(m.Centroid, pf)
+		out[i] = m
+	}
+	return out
+}
+
+// formatFeatures returns copies of features with pf set on every Position
+func formatFeatures(features []VideoMapFeature, pf positionFormat) []VideoMapFeature {
+	if features == nil {
+		return nil
+	}
+	out := make([]VideoMapFeature, len(features))
+	for i, f := range features {
+		f.Points = formatPositions(f.Points, pf)
+		if f.Lines != nil {
+			lines := make([][]Position, len(f.Lines))
+			for j, line := range f.Lines {
+				lines[j] = formatPositions(line, pf)
+			}
+			f.Lines = lines
+		}
+		f.Position = formatPosition(f.Position, pf)
+		if f.Arc != nil {
+			arc := *f.Arc
+			arc.Center.format = pf
+			f.Arc = &arc
+		}
+		out[i] = f
+	}
+	return out
+}
+
+func formatPositions(points []Position, pf positionFormat) []Position {
+	if points == nil {
+		return nil
+	}
+	out := make([]Position, len(points))
+	for i, p := range points {
+		p.format = pf
+		out[i] = p
+	}
+	return out
+}
+
+func formatPosition(p *Position, pf positionFormat) *Position {
+	if p == nil {
+		return nil
+	}
+	q := *p
+	q.format = pf
+	return &q
+}
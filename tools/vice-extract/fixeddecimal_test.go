@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPositionNoScientific(t *testing.T) {
+	p := Position{Lat: 0.0000001, Lon: -77.25}
+
+	data, err := json.Marshal([]Position{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"lat":1e-7,"lon":-77.25}]`; string(data) != want {
+		t.Fatalf("default %s, want encoding/json's form %s", data, want)
+	}
+
+	fixed := formatPositions([]Position{p}, positionFormat{fixed: true, decimals: 7})
+	if data, err = json.Marshal(fixed); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"lat":0.0000001,"lon":-77.2500000}]`; string(data) != want {
+		t.Errorf("fixed %s, want %s", data, want)
+	}
+	var back []Position
+	if err := json.Unmarshal(data, &back); err != nil || back[0] != p {
+		t.Errorf("round trip %v (%v), want %v", back, err, p)
+	}
+}
+
+func TestRenderMapsNoScientificPrecision(t *testing.T) {
+	maps := []OutputVideoMap{{ID: "m", Features: []VideoMapFeature{
+		{Type: "line", Points: []Position{{Lat: 37.5, Lon: -77.25}, {Lat: 37.51, Lon: -77.26}}},
+	}}}
+	oo := outputOptions{Compact: true, NoScientific: true, Decimals: 5}
+	wide, err := renderMaps(maps, oo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oo.Decimals = 2
+	narrow, err := renderMaps(maps, oo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(wide), `"lat":37.50000`) || !strings.Contains(string(narrow), `"lat":37.50,`) {
+		t.Errorf("want padding to each render's precision:\n%s\n%s", wide, narrow)
+	}
+	if maps[0].Features[0].Points[0].format.fixed {
+		t.Error("renderMaps modified its input")
+	}
+}
+
+func TestRenderMapsNoScientificLeavesMergeBase(t *testing.T) {
+	maps := []OutputVideoMap{{ID: "new", Features: []VideoMapFeature{
+		{Type: "line", Points: []Position{{Lat: 37.5, Lon: -77.25}, {Lat: 37.51, Lon: -77.26}}},
+	}}}
+	base := []json.RawMessage{json.RawMessage(`{"id":"hand","features":[{"type":"line","points":[{"lat":37.1234567,"lon":-77.7654321}]}]}`)}
+	data, err := renderMaps(maps, outputOptions{Compact: true, NoScientific: true, Decimals: 2, MergeBase: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"lat":37.1234567,"lon":-77.7654321`) {
+		t.Errorf("merge base coordinates changed:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"lat":37.50,"lon":-77.25`) {
+		t.Errorf("converted maps not fixed-decimal:\n%s", data)
+	}
+}
+
+func TestRenderMapsCompactNumbers(t *testing.T) {
+	maps := []OutputVideoMap{{ID: "new", Features: []VideoMapFeature{
+		{Type: "line", Points: []Position{{Lat: 37.5, Lon: -77.505}, {Lat: -0.0000001, Lon: 100}}},
+	}}}
+	base := []json.RawMessage{json.RawMessage(`{"id":"hand","features":[{"type":"line","points":[{"lat":37.500000,"lon":-77.7654321}]}]}`)}
+	data, err := renderMaps(maps, outputOptions{Compact: true, NoScientific: true, Decimals: 6, CompactNumbers: true, MergeBase: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`{"lat":37.5,"lon":-77.505}`,
+		`{"lat":0,"lon":100}`,
+		`{"lat":37.500000,"lon":-77.7654321}`, // merge base written as given
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("missing %s in:\n%s", want, data)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Synthetic Vice files (-gen-fixture)
+// A small library in both gob layouts, raw and zstd-compressed, plus a
+// manifest, for exercising the decode path without real Vice data. Doubles
+// as an example of the exact layout Vice writes.
+// ──────────────────────────────────────────────────────────────────────
+
+// fixtureLibrary returns two small maps around KRIC: an open strip and a
+// closed ring, with distinct group/category/color values
+func fixtureLibrary() VideoMapLibrary {
+	coast := VideoMap{
+		Label:    "COAST",
+		Group:    0,
+		Name:     "Fixture Coastline",
+		Id:       1,
+		Category: 0,
+		Color:    1,
+		Lines: [][]Point2LL{
+			{{-77.40, 37.40}, {-77.35, 37.45}, {-77.30, 37.50}, {-77.25, 37.55}},
+		},
+	}
+	mva := VideoMap{
+		Label:    "MVA",
+		Group:    1,
+		Name:     "Fixture MVA",
+		Id:       2,
+		Category: 3,
+		Color:    2,
+		Lines: [][]Point2LL{
+			{{-77.40, 37.40}, {-77.20, 37.40}, {-77.20, 37.60}, {-77.40, 37.60}, {-77.40, 37.40}},
+		},
+	}
+	mva.Restriction.Id = 1
+	mva.Restriction.Text = [2]string{"MVA", "030"}
+	return VideoMapLibrary{Maps: []VideoMap{coast, mva}}
+}
+
+// writeFixtures writes the fixture files into dir and returns their paths
+func writeFixtures(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	lib := fixtureLibrary()
+
+	encode := func(v any) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, fmt.Errorf("gob encode: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	library, err := encode(&lib)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := encode(lib.Maps)
+	if err != nil {
+		return nil, err
+	}
+	manifestNames := make(map[string]any, len(lib.Maps))
+	for _, vm := range lib.Maps {
+		manifestNames[vm.Name] = []string{}
+	}
+	manifest, err := encode(manifestNames)
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("zstd init: %w", err)
+	}
+	defer enc.Close()
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"fixture-videomaps.gob", library},
+		{"fixture-videomaps.gob.zst", enc.EncodeAll(library, nil)},
+		{"fixture-legacy-videomaps.gob", legacy},
+		{"fixture-legacy-videomaps.gob.zst", enc.EncodeAll(legacy, nil)},
+		{"fixture-manifest.gob", manifest},
+	}
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := writeFileAtomic(path, f.data); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
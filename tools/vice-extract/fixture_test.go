@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/gob"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixturesDecode(t *testing.T) {
+	gob.Register([]string{})
+	dir := t.TempDir()
+	if _, err := writeFixtures(dir); err != nil {
+		t.Fatal(err)
+	}
+	want := fixtureLibrary()
+
+	tests := []struct {
+		file, layout string
+	}{
+		{"fixture-videomaps.gob", layoutLibrary},
+		{"fixture-videomaps.gob.zst", layoutLibrary},
+		{"fixture-legacy-videomaps.gob", layoutLegacy},
+		{"fixture-legacy-videomaps.gob.zst", layoutLegacy},
+	}
+	for _, tt := range tests {
+		for _, mmap := range []bool{false, true} {
+			lib, layout, err := loadVideoMaps(filepath.Join(dir, tt.file), loadOptions{Format: "auto", Mmap: mmap})
+			if err != nil {
+				t.Errorf("%s (mmap %v): %v", tt.file, mmap, err)
+				continue
+			}
+			if layout != tt.layout {
+				t.Errorf("%s (mmap %v): layout %s, want %s", tt.file, mmap, layout, tt.layout)
+			}
+			if !reflect.DeepEqual(*lib, want) {
+				t.Errorf("%s (mmap %v): decoded %+v, want %+v", tt.file, mmap, *lib, want)
+			}
+		}
+	}
+
+	names, err := loadManifest(filepath.Join(dir, "fixture-manifest.gob"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(want.Maps) {
+		t.Errorf("manifest has %d names, want %d", len(names), len(want.Maps))
+	}
+}
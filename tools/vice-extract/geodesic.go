@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Great-circle densification (-geodesic-densify)
+// Map segments are straight in lat/lon, which is fine for the short
+// segments Vice draws but wrong for long ones: a 2000 nm line should
+// follow the great circle. Points are inserted along it on a sphere.
+// ──────────────────────────────────────────────────────────────────────
+
+const earthRadiusNM = 3440.065
+
+// densifyGreatCircle inserts points along the great circle between each
+// pair of consecutive points so no gap exceeds spacingNM. Existing points
+// are kept as-is.
+func densifyGreatCircle(strip []Point2LL, spacingNM float64) []Point2LL {
+	if spacingNM <= 0 || len(strip) < 2 {
+		return strip
+	}
+	out := make([]Point2LL, 0, len(strip))
+	out = append(out, strip[0])
+	for i := 1; i < len(strip); i++ {
+		a, b := unitVector(strip[i-1]), unitVector(strip[i])
+		angle := math.Acos(math.Max(-1, math.Min(1, a[0]*b[0]+a[1]*b[1]+a[2]*b[2])))
+		n := int(math.Ceil(angle * earthRadiusNM / spacingNM))
+		// Antipodal points have no unique great circle; leave the segment
+		if n > 1 && math.Sin(angle) > 1e-9 {
+			for k := 1; k < n; k++ {
+				out = append(out, slerp(a, b, angle, float64(k)/float64(n)))
+			}
+		}
+		out = append(out, strip[i])
+	}
+	return out
+}
+
+// unitVector converts a point to a unit vector on the sphere
+func unitVector(p Point2LL) [3]float64 {
+	lat, lon := float64(p[1])*math.Pi/180, float64(p[0])*math.Pi/180
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+// slerp returns the point fraction t of the way from a to b (angle apart)
+// along their great circle
+func slerp(a, b [3]float64, angle, t float64) Point2LL {
+	sa, sb := math.Sin((1-t)*angle)/math.Sin(angle), math.Sin(t*angle)/math.Sin(angle)
+	x, y, z := sa*a[0]+sb*b[0], sa*a[1]+sb*b[1], sa*a[2]+sb*b[2]
+	lat := math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi
+	lon := math.Atan2(y, x) * 180 / math.Pi
+	return Point2LL{float32(lon), float32(lat)}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDensifyGreatCircle(t *testing.T) {
+	// JFK to LAX, about 2145 nm
+	jfk, lax := Point2LL{-73.78, 40.64}, Point2LL{-118.41, 33.94}
+	got := densifyGreatCircle([]Point2LL{jfk, lax}, 100)
+
+	if len(got) != 23 || got[0] != jfk || got[len(got)-1] != lax {
+		t.Fatalf("got %d points from %v to %v, want 23 from JFK to LAX", len(got), got[0], got[len(got)-1])
+	}
+
+	// Every point on the JFK-LAX great circle plane, gaps at most 100 nm
+	a, b := unitVector(jfk), unitVector(lax)
+	normal := [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+	for i, p := range got {
+		v := unitVector(p)
+		if d := normal[0]*v[0] + normal[1]*v[1] + normal[2]*v[2]; math.Abs(d) > 1e-5 {
+			t.Errorf("point %d %v is off the great circle (%.2g)", i, p, d)
+		}
+		if i > 0 {
+			u := unitVector(got[i-1])
+			gap := math.Acos(math.Min(1, u[0]*v[0]+u[1]*v[1]+u[2]*v[2])) * earthRadiusNM
+			if gap > 100.01 {
+				t.Errorf("gap %d-%d is %.1f nm, want <= 100", i-1, i, gap)
+			}
+		}
+	}
+
+	// The great circle bows north of the straight lat/lon line
+	mid := got[len(got)/2]
+	linearLat := (jfk[1] + lax[1]) / 2
+	if mid[1] < linearLat+1 {
+		t.Errorf("midpoint latitude %.2f, want well north of the linear %.2f", mid[1], linearLat)
+	}
+
+	// Short segments are left alone
+	short := []Point2LL{{-77.3, 37.5}, {-77.29, 37.51}}
+	if got := densifyGreatCircle(short, 100); len(got) != 2 {
+		t.Errorf("short segment densified to %d points, want 2", len(got))
+	}
+}
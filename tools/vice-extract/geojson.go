@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// GeoJSON, one FeatureCollection per map (-geojson-per-map)
+// Written into the -out directory as <map id>.geojson plus index.json,
+// like -split-by-category. The map's metadata are foreign members of the
+// collection, and a named EPSG:4326 "crs" member (from the 2008 GeoJSON
+// spec; RFC 7946 dropped it but strict GIS tools still look for it)
+// states the datum. Lines become LineStrings and label features Points;
+// arc features have no point list and are left out.
+// ──────────────────────────────────────────────────────────────────────
+
+// geoJSONCollection is one map's FeatureCollection
+type geoJSONCollection struct {
+	Type string     `json:"type"` // "FeatureCollection"
+	CRS  geoJSONCRS `json:"crs"`
+
+	// Foreign members
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ShortName      string `json:"shortName"`
+	DefaultVisible bool   `json:"defaultVisible"`
+	ViceId         int    `json:"viceId"`
+	Group          int    `json:"group"`
+	Category       int    `json:"category"`
+	GroupName      string `json:"groupName,omitempty"`
+	CategoryName   string `json:"categoryName,omitempty"`
+	Color          int    `json:"color"`
+
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONCRS struct {
+	Type       string            `json:"type"` // "name"
+	Properties map[string]string `json:"properties"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"` // "Feature"
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"` // [lon, lat] or a list of them
+}
+
+// epsg4326 is the legacy named CRS for WGS 84 lon/lat
+var epsg4326 = geoJSONCRS{Type: "name", Properties: map[string]string{"name": "EPSG:4326"}}
+
+// toGeoJSON converts one map
+func toGeoJSON(m OutputVideoMap) geoJSONCollection {
+	fc := geoJSONCollection{
+		Type: "FeatureCollection", CRS: epsg4326,
+		ID: m.ID, Name: m.Name, ShortName: m.ShortName, DefaultVisible: m.DefaultVisible,
+		ViceId: m.ViceId, Group: m.Group, Category: m.Category,
+		GroupName: m.GroupName, CategoryName: m.CategoryName, Color: m.Color,
+		Features: []geoJSONFeature{},
+	}
+	for _, f := range m.Features {
+		props := map[string]any{}
+		if f.FeatureId != "" {
+			props["id"] = f.FeatureId
+		}
+		if f.Restriction != nil {
+			props["restriction"] = f.Restriction
+		}
+		var geom geoJSONGeometry
+		switch {
+		case f.Position != nil:
+			props["text"] = f.Text
+			geom = geoJSONGeometry{Type: "Point", Coordinates: [2]float64{f.Position.Lon, f.Position.Lat}}
+		case len(f.Points) >= 2:
+			coords := make([][2]float64, len(f.Points))
+			for i, p := range f.Points {
+				coords[i] = [2]float64{p.Lon, p.Lat}
+			}
+			geom = geoJSONGeometry{Type: "LineString", Coordinates: coords}
+		default:
+			continue // arcs
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{Type: "Feature", Geometry: geom, Properties: props})
+	}
+	return fc
+}
+
+// geoJSONIndexEntry describes one file written by -geojson-per-map
+type geoJSONIndexEntry struct {
+	ID   string `json:"id"`
+	File string `json:"file"`
+}
+
+// writeGeoJSONPerMap writes <id>.geojson per map and index.json into
+// outDir. Maps sharing an ID get "-2", "-3", ... file suffixes. Only
+// oo.Compact applies.
+func writeGeoJSONPerMap(outDir string, maps []OutputVideoMap, oo outputOptions) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(maps))
+	index := make([]geoJSONIndexEntry, 0, len(maps))
+	for _, m := range maps {
+		base := m.ID
+		for k := 2; taken[base]; k++ {
+			base = fmt.Sprintf("%s-%d", m.ID, k)
+		}
+		taken[base] = true
+		file := base + ".geojson"
+		n, err := writeJSON(filepath.Join(outDir, file), toGeoJSON(m), oo.Compact)
+		if err != nil {
+			return fmt.Errorf("map %s: %w", m.ID, err)
+		}
+		fmt.Fprintf(stderr, "  %-30s -> %s (%.2f MB)\n", m.Name, file, float64(n)/1024/1024)
+		index = append(index, geoJSONIndexEntry{ID: m.ID, File: file})
+	}
+	indexPath := filepath.Join(outDir, "index.json")
+	if _, err := writeJSON(indexPath, index, oo.Compact); err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+	fmt.Fprintf(stderr, "Wrote %d GeoJSON files and %s\n", len(maps), indexPath)
+	return nil
+}
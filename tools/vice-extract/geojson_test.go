@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteGeoJSONPerMap(t *testing.T) {
+	line := []Position{{Lat: 37, Lon: -77}, {Lat: 37.5, Lon: -76.5}}
+	maps := []OutputVideoMap{
+		{ID: "coast", Name: "Coast", ShortName: "COAST", ViceId: 1, Color: 2, Features: []VideoMapFeature{
+			{Type: "line", Points: line, FeatureId: "coast-0"},
+			{Type: "arc", Arc: &ArcGeometry{RadiusNM: 5}},
+			{Type: "label", Text: "COAST", Position: &Position{Lat: 37.2, Lon: -76.8}},
+		}},
+		{ID: "coast", Name: "Coast", ViceId: 2}, // same ID: gets a suffix
+	}
+	dir := t.TempDir()
+	if err := writeGeoJSONPerMap(dir, maps, outputOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var fc map[string]any
+	data, err := os.ReadFile(filepath.Join(dir, "coast.geojson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if fc["type"] != "FeatureCollection" || fc["name"] != "Coast" || fc["viceId"] != 1.0 {
+		t.Errorf("collection members: %v", fc)
+	}
+	crs := fc["crs"].(map[string]any)
+	if crs["type"] != "name" || crs["properties"].(map[string]any)["name"] != "EPSG:4326" {
+		t.Errorf("crs = %v, want named EPSG:4326", crs)
+	}
+	features := fc["features"].([]any)
+	if len(features) != 2 {
+		t.Fatalf("got %d features, want 2 (line and label; arc left out)", len(features))
+	}
+	geom := features[0].(map[string]any)["geometry"].(map[string]any)
+	coords := geom["coordinates"].([]any)
+	if geom["type"] != "LineString" || coords[0].([]any)[0] != -77.0 || coords[0].([]any)[1] != 37.0 {
+		t.Errorf("line geometry = %v, want LineString in [lon, lat]", geom)
+	}
+	if g := features[1].(map[string]any)["geometry"].(map[string]any); g["type"] != "Point" {
+		t.Errorf("label geometry = %v, want Point", g)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "coast-2.geojson")); err != nil {
+		t.Errorf("second map with ID coast: %v", err)
+	}
+	var index []geoJSONIndexEntry
+	data, _ = os.ReadFile(filepath.Join(dir, "index.json"))
+	if err := json.Unmarshal(data, &index); err != nil || len(index) != 2 || index[1].File != "coast-2.geojson" {
+		t.Errorf("index = %+v, err %v", index, err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// HTML preview (-format html)
+// One self-contained page: the converted maps embedded as JSON plus a
+// small canvas renderer with a checkbox per map (initially checked for
+// defaultVisible maps). Drag to pan, wheel to zoom. No network access is
+// needed to view it.
+// ──────────────────────────────────────────────────────────────────────
+
+// htmlPreviewData marks where the map JSON goes in htmlPreviewPage
+const htmlPreviewData = "/*MAPS*/null"
+
+// formatHTMLPreview renders the preview page for maps
+func formatHTMLPreview(maps []OutputVideoMap) ([]byte, error) {
+	// encoding/json escapes <, > and &, so the data can't close the script
+	data, err := json.Marshal(maps)
+	if err != nil {
+		return nil, fmt.Errorf("marshal JSON: %w", err)
+	}
+	title := html.EscapeString(fmt.Sprintf("vice-extract preview (%d maps)", len(maps)))
+	page := bytes.Replace([]byte(htmlPreviewPage), []byte("{{TITLE}}"), []byte(title), 2)
+	return bytes.Replace(page, []byte(htmlPreviewData), data, 1), nil
+}
+
+const htmlPreviewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{TITLE}}</title>
+<style>
+  html, body { margin: 0; height: 100%; background: #000; color: #ccc; font: 13px sans-serif; }
+  #side { position: absolute; top: 0; left: 0; bottom: 0; width: 240px; overflow-y: auto; padding: 8px; box-sizing: border-box; background: #111; }
+  #side h1 { font-size: 14px; margin: 0 0 8px; }
+  #side label { display: block; white-space: nowrap; overflow: hidden; text-overflow: ellipsis; cursor: pointer; }
+  #side label.disabled { color: #555; }
+  #map { position: absolute; top: 0; left: 240px; right: 0; bottom: 0; cursor: grab; }
+</style>
+</head>
+<body>
+<div id="side"><h1>{{TITLE}}</h1><div id="list"></div></div>
+<canvas id="map"></canvas>
+<script>
+"use strict";
+const maps = /*MAPS*/null;
+const palette = ["#c0c0c0", "#ffffff", "#00c000", "#00c0ff", "#ffff00", "#ff8000", "#ff4040", "#c080ff", "#808080"];
+const canvas = document.getElementById("map");
+const ctx = canvas.getContext("2d");
+const shown = maps.map(m => m.defaultVisible);
+
+// Equirectangular view with longitude scaled by cos(center latitude)
+let minLat = Infinity, maxLat = -Infinity, minLon = Infinity, maxLon = -Infinity;
+for (const m of maps) for (const f of m.features || []) for (const p of f.points || []) {
+  minLat = Math.min(minLat, p.lat); maxLat = Math.max(maxLat, p.lat);
+  minLon = Math.min(minLon, p.lon); maxLon = Math.max(maxLon, p.lon);
+}
+if (!isFinite(minLat)) { minLat = maxLat = minLon = maxLon = 0; }
+let centerLat = (minLat + maxLat) / 2, centerLon = (minLon + maxLon) / 2;
+const kx = Math.cos(centerLat * Math.PI / 180);
+let scale = 1;
+
+function fit() {
+  const spanX = Math.max((maxLon - minLon) * kx, 1e-6), spanY = Math.max(maxLat - minLat, 1e-6);
+  scale = 0.9 * Math.min(canvas.width / spanX, canvas.height / spanY);
+}
+function toScreen(lat, lon) {
+  return [canvas.width / 2 + (lon - centerLon) * kx * scale, canvas.height / 2 - (lat - centerLat) * scale];
+}
+
+function draw() {
+  ctx.fillStyle = "#000";
+  ctx.fillRect(0, 0, canvas.width, canvas.height);
+  ctx.lineWidth = 1;
+  maps.forEach((m, i) => {
+    if (!shown[i]) return;
+    ctx.strokeStyle = palette[m.color] || palette[0];
+    for (const f of m.features || []) {
+      let pts = f.points || [];
+      if (f.type === "arc" && f.arc) {
+        // Sample the arc; bearings are true, clockwise from north
+        const a = f.arc, n = 32;
+        let sweep = (a.endBearing - a.startBearing + 360) % 360;
+        if (!a.clockwise) sweep -= 360;
+        pts = [];
+        for (let k = 0; k <= n; k++) {
+          const b = (a.startBearing + sweep * k / n) * Math.PI / 180;
+          pts.push({
+            lat: a.center.lat + a.radiusNm * Math.cos(b) / 60,
+            lon: a.center.lon + a.radiusNm * Math.sin(b) / (60 * Math.cos(a.center.lat * Math.PI / 180)),
+          });
+        }
+      }
+      if (pts.length < 2) continue;
+      ctx.beginPath();
+      pts.forEach((p, k) => {
+        const [x, y] = toScreen(p.lat, p.lon);
+        if (k === 0) ctx.moveTo(x, y); else ctx.lineTo(x, y);
+      });
+      ctx.stroke();
+    }
+  });
+}
+
+function resize() {
+  canvas.width = canvas.clientWidth;
+  canvas.height = canvas.clientHeight;
+  draw();
+}
+
+const list = document.getElementById("list");
+maps.forEach((m, i) => {
+  const label = document.createElement("label");
+  const box = document.createElement("input");
+  box.type = "checkbox";
+  box.checked = shown[i];
+  box.disabled = !!m.disabled;
+  box.onchange = () => { shown[i] = box.checked; draw(); };
+  label.appendChild(box);
+  label.appendChild(document.createTextNode(" " + m.shortName + " — " + m.name));
+  label.title = m.id;
+  if (m.disabled) label.className = "disabled";
+  list.appendChild(label);
+});
+
+let drag = null;
+canvas.onmousedown = e => { drag = [e.clientX, e.clientY]; canvas.style.cursor = "grabbing"; };
+window.onmouseup = () => { drag = null; canvas.style.cursor = "grab"; };
+window.onmousemove = e => {
+  if (!drag) return;
+  centerLon -= (e.clientX - drag[0]) / (kx * scale);
+  centerLat += (e.clientY - drag[1]) / scale;
+  drag = [e.clientX, e.clientY];
+  draw();
+};
+canvas.onwheel = e => {
+  e.preventDefault();
+  scale *= e.deltaY < 0 ? 1.2 : 1 / 1.2;
+  draw();
+};
+window.onresize = resize;
+canvas.width = canvas.clientWidth;
+canvas.height = canvas.clientHeight;
+fit();
+draw();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatHTMLPreview(t *testing.T) {
+	maps := []OutputVideoMap{{ID: "evil", Name: "</script><b>", ShortName: "X", DefaultVisible: true}}
+	page, err := formatHTMLPreview(maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(page)
+	if strings.Contains(s, htmlPreviewData) {
+		t.Error("map data placeholder was not replaced")
+	}
+	if !strings.Contains(s, `"id":"evil"`) {
+		t.Error("page does not embed the map JSON")
+	}
+	if strings.Count(s, "</script>") != 1 {
+		t.Error("a map name closed the script element")
+	}
+	if !strings.Contains(s, "<title>vice-extract preview (1 maps)</title>") {
+		t.Error("title missing")
+	}
+}
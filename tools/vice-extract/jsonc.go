@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Lenient JSON for hand-edited config/sidecar files
+// Accepts // and /* */ comments and trailing commas. Only used for inputs
+// people edit (rename files, scenarios, ...); output is always strict JSON.
+// ──────────────────────────────────────────────────────────────────────
+
+// loadJSONConfig reads a lenient JSON file into v
+func loadJSONConfig(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(standardizeJSON(data), v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// standardizeJSON converts lenient JSON to strict JSON by blanking out
+// comments and dropping trailing commas before '}' or ']'. Comments are
+// replaced with spaces (newlines kept) so error offsets still line up.
+func standardizeJSON(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	pendingComma := -1 // index of a comma that may turn out to be trailing
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++ // skip escaped char
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			pendingComma = -1
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		case c == ',':
+			pendingComma = i
+		case c == '}' || c == ']':
+			if pendingComma >= 0 {
+				out[pendingComma] = ' '
+			}
+			pendingComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			// whitespace doesn't settle a pending comma
+		default:
+			pendingComma = -1
+		}
+	}
+	return out
+}
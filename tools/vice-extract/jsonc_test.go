@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStandardizeJSON(t *testing.T) {
+	in := `{
+	// friendlier names
+	"JRV North": "North", /* block
+	comment */
+	"A // not a comment": "B, ]",
+	"list": [1, 2, 3,],
+}`
+	var got struct {
+		North string `json:"JRV North"`
+		A     string `json:"A // not a comment"`
+		List  []int  `json:"list"`
+	}
+	if err := json.Unmarshal(standardizeJSON([]byte(in)), &got); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, standardizeJSON([]byte(in)))
+	}
+	if got.North != "North" || got.A != "B, ]" || len(got.List) != 3 {
+		t.Errorf("got %+v", got)
+	}
+}
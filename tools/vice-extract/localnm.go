@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Local nautical-mile coordinates (-coord-format localnm)
+// Points become flat offsets from -local-origin in the same equirectangular
+// projection simplification uses:
+//
+//   x = (lon - origin.lon) * nmPerDegLon(origin.lat)   (east)
+//   y = (lat - origin.lat) * nmPerDegLat(origin.lat)   (north)
+//
+// Local features carry "xy": [{x, y}, ...] instead of "points", and the
+// -wrap metadata records the origin. Distortion grows with distance from
+// the origin, so this suits a single facility's maps. Arc features keep
+// their lat/lon center.
+// ──────────────────────────────────────────────────────────────────────
+
+// localNMDecimals rounds local coordinates to 0.001 nm (about 6 ft)
+const localNMDecimals = 3
+
+// LocalPoint is a point in nm east (X) and north (Y) of the local origin
+type LocalPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// parseLatLon parses "lat,lon" in decimal degrees
+func parseLatLon(s string) (Position, error) {
+	latStr, lonStr, ok := strings.Cut(s, ",")
+	if !ok {
+		return Position{}, fmt.Errorf("%q is not \"lat,lon\"", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return Position{}, fmt.Errorf("%q: latitude must be in [-90, 90]", s)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return Position{}, fmt.Errorf("%q: longitude must be in [-180, 180]", s)
+	}
+	return Position{Lat: lat, Lon: lon}, nil
+}
+
+// toLocalNM converts p to nm offsets from origin
+func toLocalNM(p, origin Position) LocalPoint {
+	return LocalPoint{
+		X: roundCoord((p.Lon-origin.Lon)*nmPerDegLon(origin.Lat), localNMDecimals),
+		Y: roundCoord((p.Lat-origin.Lat)*nmPerDegLat(origin.Lat), localNMDecimals),
+	}
+}
+
+// localizeMaps returns copies of maps with each feature's Points replaced
+// by XY offsets from origin
+func localizeMaps(maps []OutputVideoMap, origin Position) []OutputVideoMap {
+	out := make([]OutputVideoMap, len(maps))
+	for i, m := range maps {
+		features := make([]VideoMapFeature, len(m.Features))
+		for j, f := range m.Features {
+			if len(f.Points) > 0 {
+				f.XY = make([]LocalPoint, len(f.Points))
+				for k, p := range f.Points {
+					f.XY[k] = toLocalNM(p, origin)
+				}
+				f.Points = nil
+			}
+			features[j] = f
+		}
+		m.Features = features
+		out[i] = m
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLocalizeMaps(t *testing.T) {
+	origin := Position{Lat: 37.5, Lon: -77.3}
+	maps := []OutputVideoMap{{ID: "m", Features: []VideoMapFeature{{
+		Type: "line",
+		Points: []Position{
+			origin,
+			{Lat: 38.5, Lon: -77.3}, // 1° north: 60 nm
+			{Lat: 37.5, Lon: -76.3}, // 1° east: 60·cos(37.5°) ≈ 47.601 nm
+			{Lat: 37.0, Lon: -77.8},
+		},
+	}}}}
+
+	got := localizeMaps(maps, origin)
+	if maps[0].Features[0].Points == nil {
+		t.Fatal("localizeMaps modified its input")
+	}
+	f := got[0].Features[0]
+	if f.Points != nil {
+		t.Errorf("Points = %v, want nil", f.Points)
+	}
+	east := 60 * math.Cos(37.5*math.Pi/180)
+	want := []LocalPoint{{0, 0}, {0, 60}, {east, 0}, {-east / 2, -30}}
+	if len(f.XY) != len(want) {
+		t.Fatalf("got %d points, want %d", len(f.XY), len(want))
+	}
+	for i, p := range f.XY {
+		if math.Abs(p.X-want[i].X) > 0.0006 || math.Abs(p.Y-want[i].Y) > 0.0006 {
+			t.Errorf("point %d = %+v, want %+v", i, p, want[i])
+		}
+	}
+}
+
+func TestParseLatLon(t *testing.T) {
+	p, err := parseLatLon(" 37.5, -77.3 ")
+	if err != nil || p != (Position{Lat: 37.5, Lon: -77.3}) {
+		t.Errorf("got %+v, %v", p, err)
+	}
+	for _, bad := range []string{"37.5", "91,0", "0,181", "x,1"} {
+		if _, err := parseLatLon(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Progress/diagnostic output
+// Everything the tool prints for people goes to stderr. Under -log-json,
+// stderr becomes a jsonLineWriter (one JSON object per line, level guessed
+// from the line) and key events are logged with structured fields through
+// logger instead of as text.
+// ──────────────────────────────────────────────────────────────────────
+
+var (
+	stderr io.Writer    = os.Stderr
+	logger *slog.Logger // non-nil under -log-json
+)
+
+// enableJSONLogging routes stderr output through a JSON slog handler
+func enableJSONLogging(w io.Writer) {
+	logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	stderr = &jsonLineWriter{log: logger}
+}
+
+// jsonLineWriter logs each complete line written to it as one event.
+// Partial lines are held until their newline, so a line built from several
+// writes is still one event; blank lines are dropped.
+type jsonLineWriter struct {
+	log *slog.Logger
+	buf []byte
+}
+
+func (w *jsonLineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSpace(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+		if line != "" {
+			w.log.Log(context.Background(), lineLevel(line), line)
+		}
+	}
+	return len(p), nil
+}
+
+// lineLevel infers a level from the tool's message conventions
+func lineLevel(line string) slog.Level {
+	switch {
+	case strings.HasPrefix(line, "Error"), strings.HasPrefix(line, "Invalid"),
+		strings.HasPrefix(line, "-"): // flag conflicts, e.g. "-quantize needs ..."
+		return slog.LevelError
+	case strings.HasPrefix(line, "WARNING"), strings.HasPrefix(line, "Warning"):
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestJSONLineWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &jsonLineWriter{log: slog.New(slog.NewJSONHandler(&out, nil))}
+
+	fmt.Fprintf(w, "  [  1] PCT MVA")
+	fmt.Fprintf(w, "  [2 NaN/Inf points dropped]")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\nWARNING: Requested map 'X' NOT FOUND in video map file\n")
+	fmt.Fprintf(w, "Error loading video maps: boom\n")
+
+	want := []struct{ level, msg string }{
+		{"INFO", "[  1] PCT MVA  [2 NaN/Inf points dropped]"},
+		{"WARN", "WARNING: Requested map 'X' NOT FOUND in video map file"},
+		{"ERROR", "Error loading video maps: boom"},
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i, line := range lines {
+		var ev struct{ Level, Msg string }
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("event %d is not JSON: %s", i, line)
+		}
+		if ev.Level != want[i].level || ev.Msg != want[i].msg {
+			t.Errorf("event %d = %s %q, want %s %q", i, ev.Level, ev.Msg, want[i].level, want[i].msg)
+		}
+	}
+}
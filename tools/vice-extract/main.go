@@ -11,19 +11,36 @@
 //            -filter "JRV North,JRV CSIDE,PCT MVA,..." \
 //            -clip-lat 37.505 -clip-lon -77.320 -clip-radius 80 \
 //            -out /path/to/videomaps.json
+//
+// Per-position outputs from a Vice scenario group (one file per position):
+//   go run . -videomaps /path/to/ZDC-videomaps.gob.zst \
+//            -scenario /path/to/vice/resources/scenarios/zdc/ric.json \
+//            -out /path/to/positions/
+//
+// Add -watch to re-extract whenever the inputs (or config files) change.
 
 package main
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/klauspost/compress/zstd"
 )
@@ -34,7 +51,9 @@ import (
 // Source: github.com/mmp/vice/sim/stars.go, math/latlong.go
 // ──────────────────────────────────────────────────────────────────────
 
-// Point2LL matches Vice's math.Point2LL: [longitude, latitude] as float32
+// Point2LL matches Vice's math.Point2LL: [longitude, latitude] as float32.
+// Index 0 is LONGITUDE, index 1 latitude (x before y), the reverse of
+// Position's lat/lon field order.
 type Point2LL [2]float32
 
 // VideoMap matches Vice's sim.VideoMap struct
@@ -67,11 +86,40 @@ type VideoMapLibrary struct {
 type Position struct {
 	Lat float64 `json:"lat"`
 	Lon float64 `json:"lon"`
+
+	format positionFormat // output formatting (-no-scientific), set by renderMaps
 }
 
 type VideoMapFeature struct {
-	Type   string     `json:"type"`
-	Points []Position `json:"points,omitempty"`
+	FeatureId string       `json:"id,omitempty"` // "{mapId}-{stripIndex}" (-feature-ids)
+	Type      string       `json:"type"`
+	Points    []Position   `json:"points,omitempty"`
+	Coords    [][2]int64   `json:"coords,omitempty"` // grid [x, y] in place of Points (-quantize)
+	XY        []LocalPoint `json:"xy,omitempty"`     // nm from the local origin in place of Points (-coord-format localnm)
+	// Coordinates replaces Points under -coord-format array: [lat, lon]
+	// pairs, or [lon, lat] (Vice's Point2LL order) under -vice-coord-order
+	Coordinates [][2]float64 `json:"coordinates,omitempty"`
+	Lines       [][]Position `json:"lines,omitempty"`    // every strip's points, type "multiline" only (-multiline); Points omitted
+	Bearings    []float64    `json:"bearings,omitempty"` // true bearing per segment (-include-bearings)
+	// CumLength is the distance in nm from the first point to each point
+	// along the line (-include-cumulative-length)
+	CumLength []float64    `json:"cumLength,omitempty"`
+	Arc       *ArcGeometry `json:"arc,omitempty"`      // type "arc" only (-detect-arcs); Points omitted
+	Text      string       `json:"text,omitempty"`     // type "label" only (-labels-as-features)
+	Position  *Position    `json:"position,omitempty"` // type "label" only; always lat/lon
+	// Restriction is the map's restriction, repeated per feature under
+	// -per-feature-restrictions. Vice stores restrictions per map only
+	// (VideoMap.Lines carries no per-line data), so every feature of a map
+	// shares the map-level value.
+	Restriction *OutputRestriction `json:"restriction,omitempty"`
+}
+
+// OutputRestriction mirrors Vice's VideoMap.Restriction
+type OutputRestriction struct {
+	Id        int       `json:"id"`
+	Text      [2]string `json:"text"`
+	TextBlink bool      `json:"textBlink,omitempty"`
+	HideText  bool      `json:"hideText,omitempty"`
 }
 
 type OutputVideoMap struct {
@@ -82,27 +130,96 @@ type OutputVideoMap struct {
 	ViceId         int               `json:"viceId"`
 	Group          int               `json:"group"`
 	Category       int               `json:"category"`
+	GroupName      string            `json:"groupName,omitempty"`    // from -group-names
+	CategoryName   string            `json:"categoryName,omitempty"` // from -category-names
 	Color          int               `json:"color"`
 	Features       []VideoMapFeature `json:"features"`
+	Disabled       bool              `json:"disabled,omitempty"`       // placeholder with no features (-empty-mode placeholder)
+	Centroid       *Position         `json:"centroid,omitempty"`       // length-weighted (-include-centroid)
+	BoundingCircle *BoundingCircle   `json:"boundingCircle,omitempty"` // -include-bounding-circle
+	// ZOrder is the draw priority (-z-order-file): the file's value for the
+	// map, else its Group. Higher draws on top.
+	ZOrder *int `json:"zOrder,omitempty"`
+}
+
+// OutputMetadata describes where a wrapped output came from (-wrap)
+type OutputMetadata struct {
+	Generator string `json:"generator"`
+	Source    string `json:"source,omitempty"` // input file name
+	// Vice's gob files carry no version information, so the Vice release is
+	// only known if given with -source-version
+	SourceVersion string `json:"sourceVersion,omitempty"`
+	// LocalOrigin is the origin of "xy" coordinates (-coord-format localnm)
+	LocalOrigin *Position `json:"localOrigin,omitempty"`
+	// CoordOrder is the "coordinates" pair order (-coord-format array)
+	CoordOrder string `json:"coordOrder,omitempty"`
+}
+
+// WrappedOutput is the -wrap file layout: metadata plus the usual map array
+type WrappedOutput struct {
+	Metadata  OutputMetadata     `json:"metadata"`
+	Transform *QuantizeTransform `json:"transform,omitempty"` // -quantize only
+	Maps      any                `json:"maps"`
+	Removed   []string           `json:"removed,omitempty"` // IDs gone since the -since file
+}
+
+// MinimalVideoMap is OutputVideoMap without the Vice-internal numbering
+// (viceId, group, category, color), written under -minimal-fields
+type MinimalVideoMap struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	ShortName      string            `json:"shortName"`
+	DefaultVisible bool              `json:"defaultVisible"`
+	GroupName      string            `json:"groupName,omitempty"`
+	CategoryName   string            `json:"categoryName,omitempty"`
+	Features       []VideoMapFeature `json:"features"`
+	Disabled       bool              `json:"disabled,omitempty"`
+	Centroid       *Position         `json:"centroid,omitempty"`
+	BoundingCircle *BoundingCircle   `json:"boundingCircle,omitempty"`
+	ZOrder         *int              `json:"zOrder,omitempty"`
 }
 
 // ──────────────────────────────────────────────────────────────────────
 // Geographic utilities
 // ──────────────────────────────────────────────────────────────────────
 
-const nmPerDegLat = 60.0 // 1 degree latitude ≈ 60 nm
+// nmPerDegLat returns the length of one degree of latitude at lat, in nm,
+// under the -earth-model in use
+func nmPerDegLat(lat float64) float64 {
+	if earthModel == earthModelWGS84 {
+		return wgs84MeridianNMPerDeg(lat)
+	}
+	return 60.0 // 1 degree latitude ≈ 60 nm
+}
 
+// nmPerDegLon returns the length of one degree of longitude at lat, in nm,
+// under the -earth-model in use
 func nmPerDegLon(lat float64) float64 {
+	if earthModel == earthModelWGS84 {
+		return wgs84ParallelNMPerDeg(lat)
+	}
 	return 60.0 * math.Cos(lat*math.Pi/180.0)
 }
 
 // distanceNM returns approximate distance in nautical miles between two points
 func distanceNM(lat1, lon1, lat2, lon2 float64) float64 {
-	dlat := (lat2 - lat1) * nmPerDegLat
+	dlat := (lat2 - lat1) * nmPerDegLat((lat1+lat2)/2)
 	dlon := (lon2 - lon1) * nmPerDegLon((lat1+lat2)/2)
 	return math.Sqrt(dlat*dlat + dlon*dlon)
 }
 
+// bearingDeg returns the true bearing (0-360) from p1 to p2 using the
+// flat-earth delta, which is accurate for the short segments in video maps
+func bearingDeg(p1, p2 Position) float64 {
+	dy := (p2.Lat - p1.Lat) * nmPerDegLat((p1.Lat+p2.Lat)/2)
+	dx := (p2.Lon - p1.Lon) * nmPerDegLon((p1.Lat+p2.Lat)/2)
+	b := math.Atan2(dx, dy) * 180.0 / math.Pi
+	if b < 0 {
+		b += 360
+	}
+	return b
+}
+
 // roundCoord rounds a coordinate to n decimal places
 // 5 decimal places ≈ 1.1m accuracy (more than sufficient for radar display)
 func roundCoord(v float64, decimals int) float64 {
@@ -115,281 +232,3046 @@ func roundCoord(v float64, decimals int) float64 {
 // ──────────────────────────────────────────────────────────────────────
 
 func main() {
-	manifestPath := flag.String("manifest", "", "Path to manifest .gob file")
-	videomapPath := flag.String("videomaps", "", "Path to videomaps .gob.zst file")
+	var manifestPaths stringList
+	flag.Var(&manifestPaths, "manifest", "Path or http(s) URL of a manifest .gob file (repeat to merge several)")
+	videomapPath := flag.String("videomaps", "", "Path or http(s) URL of the videomaps .gob.zst file")
+	httpTimeout := flag.Duration("http-timeout", 60*time.Second, "Timeout for fetching URL inputs (0 = none)")
+	maxDecodeBytes := flag.Int64("max-decode-bytes", 2<<30, "Refuse to read more than this many (decompressed) bytes of videomaps gob, guarding against huge allocations (0 = no limit)")
+	useMmap := flag.Bool("mmap", false, "Memory-map -videomaps instead of reading it into memory (unix only; not used with -bundle)")
+	zstdDict := flag.String("zstd-dict", "", "Path to a zstd dictionary for dictionary-compressed videomaps")
+	requireFormat := flag.String("require-format", "", "Fail unless the gob layout decoded is \"library\" or \"legacy\" (empty = accept either)")
+	bundlePath := flag.String("bundle", "", "Zip or tar archive holding the videomaps (and optionally manifest); replaces -videomaps/-manifest")
+	inputFormat := flag.String("input-format", "auto", "Videomaps encoding: auto, zstd, gzip, or gob (uncompressed)")
 	filterNames := flag.String("filter", "", "Comma-separated map names to extract (empty = all)")
-	outPath := flag.String("out", "videomaps.json", "Output JSON file path")
-	clipLat := flag.Float64("clip-lat", 0, "Center latitude for geographic clipping (0 = no clip)")
-	clipLon := flag.Float64("clip-lon", 0, "Center longitude for geographic clipping")
-	clipRadius := flag.Float64("clip-radius", 80, "Clipping radius in nautical miles")
+	filterViceIds := flag.String("filter-vice-id", "", "Comma-separated Vice map Ids to extract, in addition to any -filter names")
+	outPath := flag.String("out", "videomaps.json", "Output JSON file path (\"-\" = stdout)")
+	outCompactPath := flag.String("out-compact", "", "Additionally write compact JSON to this path (alongside -out)")
+	var clipLats, clipLons, clipRadii floatList
+	flag.Var(&clipLats, "clip-lat", "Center latitude for geographic clipping (repeat for multiple regions; unset = no clip)")
+	flag.Var(&clipLons, "clip-lon", "Center longitude for geographic clipping (one per -clip-lat)")
+	flag.Var(&clipRadii, "clip-radius", "Clipping radius in nautical miles (one, or one per -clip-lat) (default 80)")
+	clipMode := flag.String("clip-mode", clipModeInside, "Which strips clipping keeps, whole: inside (every point within one region) or intersect (at least one point within any region)")
+	normalizeLon := flag.Bool("normalize-longitude", false, "Map longitudes > 180 to lon-360 (for sources using [0,360))")
+	geodesicDensify := flag.Float64("geodesic-densify", 0, "Insert points along the great circle so no segment is longer than this many nm (0 = off)")
+	collapseCollinearFlag := flag.Bool("collapse-collinear", false, "Drop interior points lying on the straight line between their neighbors")
+	collinearTolerance := flag.Float64("collinear-tolerance", 0.001, "Max distance in nm from the line for -collapse-collinear")
+	groupNamesFile := flag.String("group-names", "", "JSON object of Group number -> name, emitted as groupName")
+	categoryNamesFile := flag.String("category-names", "", "JSON object of Category number -> name, emitted as categoryName")
+	simplifyByCategory := flag.String("simplify-by-category", "", "JSON object of map Category -> simplification tolerance in nm, overriding -simplify-tolerance for those categories")
+	detectArcs := flag.Bool("detect-arcs", false, "Emit strips that fit a circular arc as \"arc\" features (center/radius/bearings) instead of polylines")
+	arcTolerance := flag.Float64("arc-tolerance", 0.05, "Max distance in nm of any point from the fitted circle for -detect-arcs")
+	simplifyTolerance := flag.Float64("simplify-tolerance", 0, "Douglas-Peucker simplification tolerance in nm (0 = off)")
+	pointBudget := flag.Int("total-point-budget", 0, "Raise the simplification tolerance until total points fit this budget (0 = off)")
+	targetSizeKB := flag.Int("target-size-kb", 0, "Lower -precision (to no less than 1) until the output fits this many KB (0 = off)")
+	targetSizeSimplify := flag.Bool("target-size-simplify", false, "If -target-size-kb can't be met by precision alone, also raise the simplification tolerance")
+	maxPointSpacing := flag.Float64("max-point-spacing", 0, "Drop points closer than this many nm to the previous kept point (0 = off)")
+	noClipMaps := flag.String("no-clip-maps", "", "Comma-separated map names exempt from clipping (emitted whole)")
 	precision := flag.Int("precision", 5, "Coordinate decimal places (5 ≈ 1m accuracy)")
+	adaptivePrecision := flag.Bool("adaptive-precision", false, "Round points farther than -adaptive-inner-nm from every clip center to -adaptive-outer-precision decimals instead of -precision (requires -clip-lat/-clip-lon)")
+	adaptiveInnerNM := flag.Float64("adaptive-inner-nm", 40, "Distance from a clip center within which points keep full -precision (-adaptive-precision)")
+	adaptiveOuterPrecision := flag.Int("adaptive-outer-precision", 3, "Coordinate decimal places beyond -adaptive-inner-nm (3 ≈ 100m; -adaptive-precision)")
 	compact := flag.Bool("compact", false, "Compact JSON output (no indentation)")
+	winding := flag.String("winding", "", "Normalize closed strips to \"cw\" or \"ccw\" winding (empty = leave as-is)")
+	visibleFromScenario := flag.String("visible-from-scenario", "", "Vice scenario group JSON whose default_maps (any position) set DefaultVisible; maps it doesn't list use -default-visible-by")
+	scenarioJSON := flag.String("scenario-json", "", "Vice scenario group JSON: extract one position's video_maps, in its order, with DefaultVisible from its default_maps (replaces -filter)")
+	scenarioPos := flag.String("scenario-position", "", "Position (TCP) to take from -scenario-json; may be omitted if the scenario has only one")
+	scenarioPath := flag.String("scenario", "", "Vice scenario group JSON: write one output per position into the -out directory")
+	slugStrip := flag.String("slug-strip", defaultSlugStrip, "Characters treated as word separators when deriving map IDs from names")
+	idRemapFile := flag.String("id-remap-file", "", "JSON object of generated map ID -> desired ID")
+	zOrderFile := flag.String("z-order-file", "", "JSON object of Vice map name -> draw priority, emitted as zOrder (higher on top); unlisted maps use their Group")
+	renameFile := flag.String("rename-file", "", "JSON object of Vice map name -> display name (IDs still derive from the Vice name)")
+	densityReport := flag.Bool("density-report", false, "Report the densest lat/lon grid cells of the emitted points")
+	densityCell := flag.Float64("density-cell", 0.1, "Density report cell size in degrees")
+	densityTop := flag.Int("density-top", 10, "Number of densest cells to report")
+	densityOut := flag.String("density-out", "", "Write the density report to this file instead of stderr")
+	reportDupStrips := flag.Bool("report-duplicate-strips", false, "Report emitted strips that appear in more than one map")
+	defaultVisibleBy := flag.String("default-visible-by", "order", "Pick default-visible maps by \"order\" (first N non-empty) or \"points\" (top N by point count)")
+	defaultVisibleN := flag.Int("default-visible-count", 6, "Number of maps marked default-visible")
+	includeBearings := flag.Bool("include-bearings", false, "Add per-segment true bearings to each line feature")
+	includeCumLength := flag.Bool("include-cumulative-length", false, "Add each line feature's running distance in nm from its first point (\"cumLength\", one value per point)")
+	perFeatureRestrictions := flag.Bool("per-feature-restrictions", false, "Attach the map's restriction (id/text) to each feature; Vice has no per-line restrictions, so all features of a map share it")
+	featureIds := flag.Bool("feature-ids", false, "Add a stable per-feature ID (\"{mapId}-{stripIndex}\") to each feature")
+	bboxReport := flag.Bool("bbox-report", false, "Print the raw extent of the (filtered) source maps and exit without converting")
+	minimalFields := flag.Bool("minimal-fields", false, "Omit Vice-internal fields (viceId, group, category, color) from the output")
+	noScientific := flag.Bool("no-scientific", false, "Write {lat, lon} coordinates as plain decimals at -precision places, never in exponent form (e.g. 1e-7)")
+	compactNumbers := flag.Bool("compact-numbers", false, "With -no-scientific, trim insignificant trailing zeros from coordinates (37.5, not 37.50000)")
+	multiline := flag.Bool("multiline", false, "Write each map's lines as one \"multiline\" feature whose \"lines\" holds every strip's points, instead of one \"line\" feature per strip")
+	geojsonPerMap := flag.Bool("geojson-per-map", false, "Write <id>.geojson per map (a FeatureCollection with map metadata and an EPSG:4326 crs, plus index.json) into the -out directory")
+	splitByCategory := flag.Bool("split-by-category", false, "Write category-<n>.json per map Category (plus index.json) into the -out directory")
+	strictShortNames := flag.Bool("strict-shortnames", false, "Fail instead of disambiguating when generated ShortNames collide")
+	wrap := flag.Bool("wrap", false, "Write {metadata, maps} instead of a bare map array")
+	sourceVersion := flag.String("source-version", "", "Vice release the input came from, recorded in -wrap metadata")
+	allowedColorList := flag.String("allowed-colors", "", "Comma-separated palette of allowed map Color values (empty = any)")
+	onBadColor := flag.String("on-bad-color", "keep", "For maps outside -allowed-colors: \"drop\", \"default\" (recolor to the first allowed color), or \"keep\"")
+	coordFormat := flag.String("coord-format", "latlon", "Point coordinates: latlon ({lat, lon} objects), array ([lat, lon] pairs), or localnm ({x, y} nm east/north of -local-origin; requires -wrap)")
+	viceCoordOrder := flag.Bool("vice-coord-order", false, "With -coord-format array, write pairs in Vice's Point2LL order: [lon, lat] (Point2LL[0] is longitude)")
+	localOriginFlag := flag.String("local-origin", "", "Origin \"lat,lon\" for -coord-format localnm")
+	quantizeBits := flag.Int("quantize", 0, "Emit integer coordinates on a 2^bits grid per axis plus a top-level transform (requires -wrap; 0 = off)")
+	canonical := flag.Bool("canonical", false, "Write canonical JSON: sorted keys and fixed, exponent-free number formatting, for byte-stable output")
+	roundTripCheckFlag := flag.Bool("round-trip-check", false, "After writing, read each output back, re-serialize it, and fail unless it matches")
+	format := flag.String("format", "json", "Output format: \"json\" (atc-sim maps), \"wkt\" (one id<TAB>MULTILINESTRING line per map), or \"html\" (self-contained preview page)")
+	coordEpsilon := flag.Float64("coordinate-epsilon", 0, "Points within this many nm count as the same point when deciding a strip is closed (-winding, -detect-arcs, -detect-polygons); 0 = identical")
+	detectPolygons := flag.Bool("detect-polygons", false, "With -format wkt, write closed strips as POLYGONs")
+	mergeInto := flag.String("merge-into", "", "Existing atc-sim map JSON to merge into by map ID (matching IDs replaced, new maps appended); result goes to -out")
+	manifestOut := flag.String("manifest-out", "", "Write a JSON index of the output maps (id, name, shortName, defaultVisible, group, category, points) to this path")
+	legendOut := flag.String("legend-out", "", "Write a JSON array of {color, count, exampleMapName} for each distinct map color in the output to this path")
+	namesOut := flag.String("names-out", "", "Write every map name (videomaps order, then any manifest-only names) as a JSON array to this path")
+	namesOnly := flag.Bool("names-only", false, "Exit after writing -names-out, without converting")
+	explain := flag.Bool("explain", false, "Log, per source map, each filter/color/sample/clip decision and the final include/exclude verdict")
+	watch := flag.Bool("watch", false, "After extracting, poll the input and config files and re-extract whenever one changes")
+	watchInterval := flag.Duration("watch-interval", time.Second, "Polling interval for -watch")
+	genFixture := flag.String("gen-fixture", "", "Write small synthetic videomaps (library and legacy layouts, raw and zstd) and a manifest into this directory, then exit")
+	emptyMode := flag.String("empty-mode", "keep", "Maps with no features after conversion: \"keep\" as normal maps, \"drop\", or \"placeholder\" (kept with disabled: true)")
+	logJSON := flag.Bool("log-json", false, "Write progress, warnings, and errors to stderr as JSON objects (one per line) instead of text")
+	selectExpr := flag.String("select", "", "Boolean expression over name, group, category, color, points, e.g. 'category == 2 and name matches \"^JRV\" and not color == 5'")
+	decodeJobs := flag.Int("decode-jobs", 0, "zstd decoder concurrency (0 = one per CPU, 1 = synchronous)")
+	zstdMaxMemory := flag.Int64("zstd-max-memory", 0, "Cap the zstd decoder's window (and memory) at this many bytes; inputs that need more fail (0 = library default, 64 GiB)")
+	decodeBuffer := flag.Bool("decode-buffer", false, "Decompress the whole input into memory before gob decoding (faster on multi-core, needs memory for the decompressed stream)")
+	noFallback := flag.Bool("no-fallback", false, "Only try the current VideoMapLibrary layout; fail with its decode error instead of retrying as legacy []VideoMap")
+	verbose := flag.Bool("verbose", false, "Log extra diagnostics (decompressed stream sizes)")
+	includeBoundingCircle := flag.Bool("include-bounding-circle", false, "Add each map's smallest enclosing circle (\"boundingCircle\": {centerLat, centerLon, radiusNm}) for radial hit-testing")
+	includeCentroid := flag.Bool("include-centroid", false, "Add each map's length-weighted centroid (\"centroid\": {lat, lon}) for label placement")
+	mvaMin := flag.Int("mva-min", 0, "Drop MVA maps whose restriction-text altitude is below this many feet")
+	mvaMax := flag.Int("mva-max", 0, "Drop MVA maps whose restriction-text altitude is above this many feet (0 = no ceiling)")
+	summaryJSON := flag.Bool("summary-json", false, "On success, print {maps, features, points, dropped, outFile, bytes} as one JSON line on stdout")
+	excludeRegex := flag.String("exclude-regex", "", "Drop maps whose Name matches this Go regexp (unanchored), after -filter/-select")
+	schemaPath := flag.String("schema", "", "JSON Schema every written file must satisfy; violations are listed and the run fails")
+	sincePath := flag.String("since", "", "Previous atc-sim JSON output: write only maps whose features changed or are new, plus \"removed\" IDs (requires -wrap)")
+	minStripPoints := flag.Int("min-strip-points", 2, "Drop strips with fewer points (e.g. 4 for closed polygons)")
+	reverseStripOrder := flag.Bool("reverse-strip-order", false, "Emit each map's strips last to first (features otherwise follow source strip order)")
+	labelsAsFeatures := flag.Bool("labels-as-features", false, "Add a \"label\" feature with each map's Vice Label text at the centroid of its lines")
+	warnLargeMap := flag.Int("warn-large-map-points", 0, "Warn about (but keep) maps with more than this many points after conversion (0 = off)")
+	simplifySweepFlag := flag.Bool("simplify-sweep", false, "Print total points and max displacement at each -sweep-tolerances value, then exit without writing")
+	sweepTolerancesList := flag.String("sweep-tolerances", "0,0.05,0.1,0.25,0.5", "Comma-separated simplification tolerances (nm) for -simplify-sweep")
+	failOnSkip := flag.Bool("fail-on-skip", false, "Exit nonzero without writing if any map fails to convert (by default such maps are skipped with a warning)")
+	dedupe := flag.Bool("dedupe-maps", false, "Drop maps whose features, name, short name, group, category, and color match an earlier map (the first is kept)")
+	postCommand := flag.String("post-command", "", "Pipe each output file through this program (stdin -> stdout) before writing; split on spaces, no shell; nonzero exit aborts")
+	shortNameLength := flag.Int("short-name-length", defaultShortNameLength, fmt.Sprintf("Truncate generated ShortNames to this many characters (1-%d; well-known maps keep their fixed names)", maxShortNameLength))
+	emitClipOutline := flag.Bool("emit-clip-outline", false, "Append a synthetic map (id \"clip-boundary\") outlining each clip region, to show what was clipped")
+	detectSwapped := flag.Bool("detect-swapped-coords", false, "Warn about maps whose points look like [lat, lon] instead of [lon, lat] (far from the clip center or the library's median point, but not when swapped)")
+	repairSwapped := flag.Bool("repair-swapped", false, "Swap lat/lon back in maps flagged by -detect-swapped-coords (implies it)")
+	earthModelFlag := flag.String("earth-model", earthModelSphere, "Degree lengths for distances and projections: sphere (60 nm per degree latitude, cosine for longitude) or wgs84 (ellipsoid, accurate near the poles)")
+	normalizeNames := flag.Bool("normalize-names", false, "Trim map names and collapse internal runs of whitespace to one space before generating IDs and ShortNames (changed names are logged)")
+	cpuProfile := flag.String("cpuprofile", "", "Write a pprof CPU profile of the whole run to this file")
+	memProfile := flag.String("memprofile", "", "Write a pprof heap profile to this file when the run completes")
+	sample := flag.Int("sample", 0, "Keep every Nth map after filtering, for spot checks (0 = all)")
 	flag.Parse()
 
-	if *videomapPath == "" {
-		fmt.Fprintf(os.Stderr, "Usage: vice-extract -videomaps <path> [options]\n\n")
-		fmt.Fprintf(os.Stderr, "Options:\n")
-		flag.PrintDefaults()
-		os.Exit(1)
+	if *logJSON {
+		enableJSONLogging(os.Stderr)
 	}
 
-	doClip := *clipLat != 0
+	switch *earthModelFlag {
+	case earthModelSphere, earthModelWGS84:
+		earthModel = *earthModelFlag
+	default:
+		fmt.Fprintf(stderr, "Invalid -earth-model %q (want \"sphere\" or \"wgs84\")\n", *earthModelFlag)
+		os.Exit(1)
+	}
 
-	// Register []string for gob interface decoding
-	// (manifest uses map[string]any which may contain []string values)
-	gob.Register([]string{})
+	stopProfiling, err := startProfiling(*cpuProfile, *memProfile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error starting profiling: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := stopProfiling(); err != nil {
+			fmt.Fprintf(stderr, "WARNING: Writing profile: %v\n", err)
+		}
+	}()
 
-	// 1. Load and display manifest if provided
-	if *manifestPath != "" {
-		names, err := loadManifest(*manifestPath)
+	if *genFixture != "" {
+		gob.Register([]string{})
+		paths, err := writeFixtures(*genFixture)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Failed to load manifest: %v\n", err)
-		} else {
-			fmt.Fprintf(os.Stderr, "Manifest contains %d map names\n\n", len(names))
+			fmt.Fprintf(stderr, "Error writing fixtures: %v\n", err)
+			os.Exit(1)
+		}
+		for _, p := range paths {
+			fmt.Fprintf(stderr, "Wrote %s\n", p)
 		}
+		return
 	}
 
-	// 2. Load video map library
-	fmt.Fprintf(os.Stderr, "Loading video maps from %s...\n", *videomapPath)
-	vmLib, err := loadVideoMaps(*videomapPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading video maps: %v\n", err)
+	if *videomapPath == "" && *bundlePath == "" {
+		fmt.Fprintf(stderr, "Usage: vice-extract -videomaps <path> [options]\n")
+		fmt.Fprintf(stderr, "       vice-extract -bundle <zip|tar> [options]\n\n")
+		fmt.Fprintf(stderr, "Options:\n")
+		flag.PrintDefaults()
 		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "Loaded %d total video maps from file\n", len(vmLib.Maps))
 
-	if doClip {
-		fmt.Fprintf(os.Stderr, "Clipping to %.1f nm radius around (%.3f, %.3f)\n", *clipRadius, *clipLat, *clipLon)
+	if !slices.Contains(inputFormats, *inputFormat) {
+		fmt.Fprintf(stderr, "Invalid -input-format %q (want one of %s)\n", *inputFormat, strings.Join(inputFormats, ", "))
+		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "Coordinate precision: %d decimal places\n\n", *precision)
-
-	// 3. Build filter set from comma-separated names
-	filterSet := make(map[string]bool)
-	if *filterNames != "" {
-		for _, name := range strings.Split(*filterNames, ",") {
-			name = strings.TrimSpace(name)
-			if name != "" {
-				filterSet[name] = true
-			}
+	if *emptyMode != "keep" && *emptyMode != "drop" && *emptyMode != "placeholder" {
+		fmt.Fprintf(stderr, "Invalid -empty-mode %q (want \"keep\", \"drop\", or \"placeholder\")\n", *emptyMode)
+		os.Exit(1)
+	}
+	if *defaultVisibleBy != "order" && *defaultVisibleBy != "points" {
+		fmt.Fprintf(stderr, "Invalid -default-visible-by %q (want \"order\" or \"points\")\n", *defaultVisibleBy)
+		os.Exit(1)
+	}
+	if *requireFormat != "" && *requireFormat != layoutLibrary && *requireFormat != layoutLegacy {
+		fmt.Fprintf(stderr, "Invalid -require-format %q (want %q or %q)\n", *requireFormat, layoutLibrary, layoutLegacy)
+		os.Exit(1)
+	}
+	if *outPath == "-" && (*scenarioPath != "" || *splitByCategory) {
+		fmt.Fprintf(stderr, "-out - (stdout) cannot be used with -scenario or -split-by-category, which write a directory\n")
+		os.Exit(1)
+	}
+	allowedColors, err := parseIntList(*allowedColorList)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid -allowed-colors: %v\n", err)
+		os.Exit(1)
+	}
+	viceIds, err := parseIntList(*filterViceIds)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid -filter-vice-id: %v\n", err)
+		os.Exit(1)
+	}
+	filterIds := make(map[int]bool, len(viceIds))
+	for _, id := range viceIds {
+		filterIds[id] = true
+	}
+	if *onBadColor != "drop" && *onBadColor != "default" && *onBadColor != "keep" {
+		fmt.Fprintf(stderr, "Invalid -on-bad-color %q (want \"drop\", \"default\", or \"keep\")\n", *onBadColor)
+		os.Exit(1)
+	}
+	if *sourceVersion != "" && !*wrap {
+		fmt.Fprintf(stderr, "-source-version is recorded in the output metadata; add -wrap\n")
+		os.Exit(1)
+	}
+	if *decodeJobs < 0 {
+		fmt.Fprintf(stderr, "Invalid -decode-jobs %d (must be >= 0)\n", *decodeJobs)
+		os.Exit(1)
+	}
+	if *mvaMin < 0 || *mvaMax < 0 || (*mvaMax > 0 && *mvaMax < *mvaMin) {
+		fmt.Fprintf(stderr, "Invalid MVA band %d-%d ft (want 0 <= -mva-min <= -mva-max, or -mva-max 0 for no ceiling)\n", *mvaMin, *mvaMax)
+		os.Exit(1)
+	}
+	var schema *jsonSchema
+	if *schemaPath != "" {
+		if *format != "json" {
+			fmt.Fprintf(stderr, "-schema only applies to -format json\n")
+			os.Exit(1)
+		}
+		var err error
+		if schema, err = loadJSONSchema(*schemaPath); err != nil {
+			fmt.Fprintf(stderr, "Error loading -schema: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Fprintf(os.Stderr, "Filtering to %d requested maps\n\n", len(filterSet))
 	}
-
-	// 4. Convert matching maps to our JSON format
-	var outputMaps []OutputVideoMap
-	defaultVisibleCount := 0
-	totalPointsBefore := 0
-	totalPointsAfter := 0
-	totalFeaturesBefore := 0
-	totalFeaturesAfter := 0
-
-	for _, vm := range vmLib.Maps {
-		// Skip if not in filter set
-		if len(filterSet) > 0 && !filterSet[vm.Name] {
-			continue
+	var excludeRE *regexp.Regexp
+	if *excludeRegex != "" {
+		var err error
+		if excludeRE, err = regexp.Compile(*excludeRegex); err != nil {
+			fmt.Fprintf(stderr, "Invalid -exclude-regex: %v\n", err)
+			os.Exit(1)
 		}
+	}
 
-		// Count before clipping
-		for _, strip := range vm.Lines {
-			totalFeaturesBefore++
-			totalPointsBefore += len(strip)
+	if *warnLargeMap < 0 {
+		fmt.Fprintf(stderr, "Invalid -warn-large-map-points %d (must be >= 0)\n", *warnLargeMap)
+		os.Exit(1)
+	}
+	if *sample < 0 {
+		fmt.Fprintf(stderr, "Invalid -sample %d (must be >= 0)\n", *sample)
+		os.Exit(1)
+	}
+	sweepTolerances, err := parseFloatList(*sweepTolerancesList)
+	if err != nil || len(sweepTolerances) == 0 || slices.ContainsFunc(sweepTolerances, func(t float64) bool { return t < 0 }) {
+		fmt.Fprintf(stderr, "Invalid -sweep-tolerances %q (want comma-separated tolerances >= 0 in nm)\n", *sweepTolerancesList)
+		os.Exit(1)
+	}
+	if *minStripPoints < 2 {
+		fmt.Fprintf(stderr, "Invalid -min-strip-points %d (must be >= 2)\n", *minStripPoints)
+		os.Exit(1)
+	}
+	if *zstdMaxMemory < 0 {
+		fmt.Fprintf(stderr, "Invalid -zstd-max-memory %d (must be >= 0)\n", *zstdMaxMemory)
+		os.Exit(1)
+	}
+	if *shortNameLength < 1 || *shortNameLength > maxShortNameLength {
+		fmt.Fprintf(stderr, "Invalid -short-name-length %d (want 1 to %d)\n", *shortNameLength, maxShortNameLength)
+		os.Exit(1)
+	}
+	if *coordEpsilon < 0 {
+		fmt.Fprintf(stderr, "Invalid -coordinate-epsilon %g (must be >= 0)\n", *coordEpsilon)
+		os.Exit(1)
+	}
+	if *maxDecodeBytes < 0 {
+		fmt.Fprintf(stderr, "Invalid -max-decode-bytes %d (must be >= 0)\n", *maxDecodeBytes)
+		os.Exit(1)
+	}
+	if *format != "json" && *format != "wkt" && *format != "html" {
+		fmt.Fprintf(stderr, "Invalid -format %q (want \"json\", \"wkt\", or \"html\")\n", *format)
+		os.Exit(1)
+	}
+	if *format != "json" && (*scenarioPath != "" || *splitByCategory || *wrap || *quantizeBits > 0 || *mergeInto != "") {
+		fmt.Fprintf(stderr, "-format %s writes a single file; it cannot be used with -scenario, -split-by-category, -wrap, -quantize, or -merge-into\n", *format)
+		os.Exit(1)
+	}
+	if *roundTripCheckFlag && (*format != "json" || *mergeInto != "" || *outPath == "-" || *postCommand != "") {
+		fmt.Fprintf(stderr, "-round-trip-check needs JSON written to a file, without -merge-into or -post-command\n")
+		os.Exit(1)
+	}
+	if *canonical && *format != "json" {
+		fmt.Fprintf(stderr, "-canonical only applies to -format json\n")
+		os.Exit(1)
+	}
+	if *detectPolygons && *format != "wkt" {
+		fmt.Fprintf(stderr, "-detect-polygons only applies to -format wkt\n")
+		os.Exit(1)
+	}
+	if *scenarioJSON != "" && (*scenarioPath != "" || *visibleFromScenario != "" || *filterNames != "" || *filterViceIds != "") {
+		fmt.Fprintf(stderr, "-scenario-json sets the maps and their visibility; it cannot be used with -scenario, -visible-from-scenario, -filter, or -filter-vice-id\n")
+		os.Exit(1)
+	}
+	if *scenarioPos != "" && *scenarioJSON == "" {
+		fmt.Fprintf(stderr, "-scenario-position only applies with -scenario-json\n")
+		os.Exit(1)
+	}
+	// -scenario writes each position's own map list with its own default
+	// maps; the selection, visibility, and report pipeline doesn't run
+	if *scenarioPath != "" && (*filterNames != "" || *filterViceIds != "" || *selectExpr != "" || *excludeRegex != "" ||
+		*allowedColorList != "" || *mvaMin != 0 || *mvaMax != 0 || *sample > 1 || *emptyMode != "keep" || *dedupe ||
+		*strictShortNames || *pointBudget > 0 || *targetSizeKB > 0 || *defaultVisibleBy != "order" ||
+		*manifestOut != "" || *legendOut != "" || *densityReport || *reportDupStrips || *simplifySweepFlag ||
+		*explain || *warnLargeMap > 0) {
+		fmt.Fprintf(stderr, "-scenario takes each position's maps and default maps from the scenario; it cannot be used with -filter, -filter-vice-id, -select, -exclude-regex, -allowed-colors, -mva-min/-mva-max, -sample, -empty-mode, -dedupe-maps, -strict-shortnames, -total-point-budget, -target-size-kb, -default-visible-by, -manifest-out, -legend-out, -density-report, -report-duplicate-strips, -simplify-sweep, -explain, or -warn-large-map-points\n")
+		os.Exit(1)
+	}
+	if *bboxReport && *scenarioPath != "" {
+		fmt.Fprintf(stderr, "-bbox-report reports without converting; it cannot be used with -scenario, which writes every position's file\n")
+		os.Exit(1)
+	}
+	if *visibleFromScenario != "" && *scenarioPath != "" {
+		fmt.Fprintf(stderr, "-visible-from-scenario cannot be used with -scenario (each position already gets its own default_maps)\n")
+		os.Exit(1)
+	}
+	if *sincePath != "" && (!*wrap || *format != "json" || *scenarioPath != "" || *splitByCategory || *mergeInto != "" || *quantizeBits > 0 || *multiline) {
+		fmt.Fprintf(stderr, "-since writes one delta file with \"removed\" in the -wrap envelope; it needs -wrap and -format json, without -scenario, -split-by-category, -merge-into, -quantize, or -multiline (which changes every map's features)\n")
+		os.Exit(1)
+	}
+	if *sincePath != "" && *coordFormat != "latlon" {
+		fmt.Fprintf(stderr, "-since cannot be combined with -coord-format %s (features are compared in {lat, lon} form)\n", *coordFormat)
+		os.Exit(1)
+	}
+	if *geojsonPerMap && (*outPath == "-" || *scenarioPath != "" || *splitByCategory || *format != "json" || *wrap ||
+		*quantizeBits > 0 || *coordFormat != "latlon" || *mergeInto != "" || *sincePath != "" || *summaryJSON ||
+		*schemaPath != "" || *roundTripCheckFlag || *postCommand != "") {
+		fmt.Fprintf(stderr, "-geojson-per-map writes its own files into the -out directory; it cannot be used with -out -, -scenario, -split-by-category, -format, -wrap, -quantize, -coord-format, -merge-into, -since, -summary-json, -schema, -round-trip-check, or -post-command\n")
+		os.Exit(1)
+	}
+	if *multiline && (*format != "json" || *quantizeBits > 0 || *coordFormat != "latlon" || *geojsonPerMap ||
+		*includeBearings || *includeCumLength || *featureIds) {
+		fmt.Fprintf(stderr, "-multiline needs -format json; it cannot be used with -quantize, -coord-format, -geojson-per-map, -include-bearings, -include-cumulative-length, or -feature-ids\n")
+		os.Exit(1)
+	}
+	if *compactNumbers && !*noScientific {
+		fmt.Fprintf(stderr, "-compact-numbers trims the zeros -no-scientific pads with; add -no-scientific (without it coordinates are already written in their shortest form)\n")
+		os.Exit(1)
+	}
+	if *noScientific && (*coordFormat != "latlon" || *adaptivePrecision || *canonical || *geojsonPerMap) {
+		fmt.Fprintf(stderr, "-no-scientific pads {lat, lon} objects to -precision; it cannot be used with -coord-format array or localnm, -adaptive-precision, -canonical, or -geojson-per-map\n")
+		os.Exit(1)
+	}
+	if *mergeInto != "" && (*scenarioPath != "" || *splitByCategory) {
+		fmt.Fprintf(stderr, "-merge-into writes a single file; it cannot be used with -scenario or -split-by-category\n")
+		os.Exit(1)
+	}
+	if *mergeInto != "" && *quantizeBits > 0 {
+		fmt.Fprintf(stderr, "-merge-into cannot be used with -quantize (existing maps aren't on the grid)\n")
+		os.Exit(1)
+	}
+	if *outCompactPath != "" && (*scenarioPath != "" || *splitByCategory || *geojsonPerMap) {
+		fmt.Fprintf(stderr, "-out-compact writes a second copy of a single output file; it cannot be used with -scenario, -split-by-category, or -geojson-per-map\n")
+		os.Exit(1)
+	}
+	if *summaryJSON && (*outPath == "-" || *namesOut == "-" || *scenarioPath != "" || *splitByCategory) {
+		fmt.Fprintf(stderr, "-summary-json prints to stdout for a single output file; it cannot be used with -out -, -names-out -, -scenario, or -split-by-category\n")
+		os.Exit(1)
+	}
+	if *manifestOut == "-" && (*outPath == "-" || *summaryJSON) {
+		fmt.Fprintf(stderr, "-manifest-out - cannot share stdout with -out - or -summary-json\n")
+		os.Exit(1)
+	}
+	if *legendOut == "-" && (*outPath == "-" || *summaryJSON || *manifestOut == "-") {
+		fmt.Fprintf(stderr, "-legend-out - cannot share stdout with -out -, -summary-json, or -manifest-out -\n")
+		os.Exit(1)
+	}
+	if *namesOnly && *namesOut == "" {
+		fmt.Fprintf(stderr, "-names-only needs -names-out\n")
+		os.Exit(1)
+	}
+	if *namesOut == "-" && *outPath == "-" && !*namesOnly {
+		fmt.Fprintf(stderr, "-names-out and -out cannot both be stdout (add -names-only)\n")
+		os.Exit(1)
+	}
+	if *quantizeBits < 0 || *quantizeBits > 30 {
+		fmt.Fprintf(stderr, "Invalid -quantize %d (want 1-30 bits, or 0 for off)\n", *quantizeBits)
+		os.Exit(1)
+	}
+	if *quantizeBits > 0 && !*wrap {
+		fmt.Fprintf(stderr, "-quantize needs the top-level transform in the output envelope; add -wrap\n")
+		os.Exit(1)
+	}
+	var localOrigin *Position
+	var coordOrder string
+	if *viceCoordOrder && *coordFormat != "array" {
+		fmt.Fprintf(stderr, "-vice-coord-order only applies to -coord-format array\n")
+		os.Exit(1)
+	}
+	switch *coordFormat {
+	case "array":
+		coordOrder = coordOrderLatLon
+		if *viceCoordOrder {
+			coordOrder = coordOrderLonLat
 		}
-
-		// First 6 non-empty maps default to visible
-		isDefaultVisible := defaultVisibleCount < 6 && len(vm.Lines) > 0
-		outMap := convertMap(vm, isDefaultVisible, doClip, *clipLat, *clipLon, *clipRadius, *precision)
-
-		// Count after conversion
-		for _, f := range outMap.Features {
-			totalFeaturesAfter++
-			totalPointsAfter += len(f.Points)
+		if *format != "json" || *quantizeBits > 0 {
+			fmt.Fprintf(stderr, "-coord-format array needs -format json, without -quantize\n")
+			os.Exit(1)
 		}
-
-		outputMaps = append(outputMaps, outMap)
-		if len(vm.Lines) > 0 {
-			defaultVisibleCount++
+		fallthrough
+	case "latlon":
+		if *localOriginFlag != "" {
+			fmt.Fprintf(stderr, "-local-origin only applies to -coord-format localnm\n")
+			os.Exit(1)
 		}
-
-		// Statistics
-		fmt.Fprintf(os.Stderr, "  [%3d] %-25s  %5d features, %7d points",
-			vm.Id, vm.Name, len(outMap.Features), countPoints(outMap))
-		if doClip {
-			origPts := 0
-			for _, s := range vm.Lines {
-				origPts += len(s)
-			}
-			if origPts > 0 {
-				pct := float64(countPoints(outMap)) / float64(origPts) * 100
-				fmt.Fprintf(os.Stderr, "  (%.0f%% of %d)", pct, origPts)
-			}
+	case "localnm":
+		if *localOriginFlag == "" {
+			fmt.Fprintf(stderr, "-coord-format localnm needs -local-origin \"lat,lon\"\n")
+			os.Exit(1)
 		}
-		fmt.Fprintln(os.Stderr)
-	}
-
-	// 5. Report missing maps
-	if len(filterSet) > 0 {
-		foundSet := make(map[string]bool)
-		for _, m := range outputMaps {
-			foundSet[m.Name] = true
+		origin, err := parseLatLon(*localOriginFlag)
+		if err != nil {
+			fmt.Fprintf(stderr, "Invalid -local-origin %v\n", err)
+			os.Exit(1)
 		}
-		for name := range filterSet {
-			if !foundSet[name] {
-				fmt.Fprintf(os.Stderr, "  WARNING: Requested map '%s' NOT FOUND in video map file\n", name)
-			}
+		localOrigin = &origin
+		if !*wrap || *format != "json" || *quantizeBits > 0 || *mergeInto != "" {
+			fmt.Fprintf(stderr, "-coord-format localnm records its origin in the -wrap metadata; it needs -wrap and -format json, without -quantize or -merge-into\n")
+			os.Exit(1)
 		}
+	default:
+		fmt.Fprintf(stderr, "Invalid -coord-format %q (want \"latlon\", \"array\", or \"localnm\")\n", *coordFormat)
+		os.Exit(1)
 	}
-
-	fmt.Fprintf(os.Stderr, "\nSummary: %d maps, %d features (%d before), %d points (%d before)\n",
-		len(outputMaps), totalFeaturesAfter, totalFeaturesBefore, totalPointsAfter, totalPointsBefore)
-
-	// 6. Write output JSON
-	var data []byte
-	if *compact {
-		data, err = json.Marshal(outputMaps)
-	} else {
-		data, err = json.MarshalIndent(outputMaps, "", "  ")
+	if *densityReport && *densityCell <= 0 {
+		fmt.Fprintf(stderr, "Invalid -density-cell %g (must be > 0)\n", *densityCell)
+		os.Exit(1)
 	}
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+	if *collinearTolerance < 0 {
+		fmt.Fprintf(stderr, "Invalid -collinear-tolerance %g (must be >= 0)\n", *collinearTolerance)
 		os.Exit(1)
 	}
-	if err := os.WriteFile(*outPath, data, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+	if *targetSizeKB < 0 {
+		fmt.Fprintf(stderr, "Invalid -target-size-kb %d (must be >= 0)\n", *targetSizeKB)
 		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "Wrote %s (%.2f MB)\n", *outPath, float64(len(data))/1024/1024)
-}
-
-func countPoints(m OutputVideoMap) int {
-	n := 0
-	for _, f := range m.Features {
-		n += len(f.Points)
+	if *geodesicDensify < 0 {
+		fmt.Fprintf(stderr, "Invalid -geodesic-densify %g (must be >= 0)\n", *geodesicDensify)
+		os.Exit(1)
+	}
+	if *winding != "" && *winding != "cw" && *winding != "ccw" {
+		fmt.Fprintf(stderr, "Invalid -winding %q (want \"cw\" or \"ccw\")\n", *winding)
+		os.Exit(1)
+	}
+	if *watch && (isURL(*videomapPath) || slices.ContainsFunc(manifestPaths, isURL)) {
+		fmt.Fprintf(stderr, "-watch polls local files; it cannot watch URL inputs\n")
+		os.Exit(1)
+	}
+	if *useMmap && isURL(*videomapPath) {
+		fmt.Fprintf(stderr, "-mmap needs a local -videomaps file, not a URL\n")
+		os.Exit(1)
+	}
+	if *watch && *watchInterval <= 0 {
+		fmt.Fprintf(stderr, "Invalid -watch-interval %s (must be > 0)\n", *watchInterval)
+		os.Exit(1)
 	}
-	return n
-}
-
-// ──────────────────────────────────────────────────────────────────────
-// Loading Vice binary formats
-// ──────────────────────────────────────────────────────────────────────
 
-func loadManifest(path string) (map[string]any, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
+	if *watch && os.Getenv(watchChildEnv) == "" {
+		var watched []string
+		for _, p := range append([]string{
+			*videomapPath, *bundlePath, *zstdDict, *scenarioPath, *scenarioJSON, *visibleFromScenario,
+			*renameFile, *idRemapFile, *simplifyByCategory, *groupNamesFile, *categoryNamesFile,
+			*zOrderFile, *schemaPath,
+		}, manifestPaths...) {
+			if p != "" {
+				watched = append(watched, p)
+			}
+		}
+		if err := runWatch(watched, *watchInterval); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
-	defer f.Close()
 
-	var names map[string]any
-	if err := gob.NewDecoder(f).Decode(&names); err != nil {
-		return nil, fmt.Errorf("gob decode manifest: %w", err)
+	var renames map[string]string
+	if *renameFile != "" {
+		var err error
+		renames, err = loadStringMap(*renameFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading rename file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var zOrders map[string]int
+	if *zOrderFile != "" {
+		if err := loadJSONConfig(*zOrderFile, &zOrders); err != nil {
+			fmt.Fprintf(stderr, "Error loading -z-order-file: %v\n", err)
+			os.Exit(1)
+		}
+		if zOrders == nil {
+			zOrders = map[string]int{} // "{}" or "null": every map uses its Group
+		}
+	}
+	var idRemap map[string]string
+	if *idRemapFile != "" {
+		var err error
+		idRemap, err = loadStringMap(*idRemapFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading ID remap file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var scenarioVisible, scenarioMentioned map[string]bool
+	scenarioSource := *visibleFromScenario // for the summary
+	if *visibleFromScenario != "" {
+		sg, err := loadScenario(*visibleFromScenario)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -visible-from-scenario: %v\n", err)
+			os.Exit(1)
+		}
+		scenarioVisible, scenarioMentioned = scenarioVisibility(sg.STARSConfig.ControllerConfigs)
+	}
+	var scenarioOrder []string // -scenario-json: the position's maps, in order
+	if *scenarioJSON != "" {
+		sg, err := loadScenario(*scenarioJSON)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -scenario-json: %v\n", err)
+			os.Exit(1)
+		}
+		pos, cc, err := scenarioPosition(sg, *scenarioPos)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error in -scenario-json: %v\n", err)
+			os.Exit(1)
+		}
+		scenarioOrder, scenarioSource = cc.VideoMaps, *scenarioJSON+" ("+pos+")"
+		scenarioVisible, scenarioMentioned = scenarioVisibility(map[string]ViceControllerConfig{pos: cc})
+		fmt.Fprintf(stderr, "Scenario position %s: %d maps (%d default visible)\n", pos, len(cc.VideoMaps), len(cc.DefaultMaps))
+	}
+	var categoryTolerances map[int]float64
+	if *simplifyByCategory != "" {
+		var err error
+		categoryTolerances, err = loadCategoryTolerances(*simplifyByCategory)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -simplify-by-category: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var groupNames, categoryNames map[int]string
+	if *groupNamesFile != "" {
+		if groupNames, err = loadNameTable(*groupNamesFile, "group"); err != nil {
+			fmt.Fprintf(stderr, "Error loading -group-names: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *categoryNamesFile != "" {
+		if categoryNames, err = loadNameTable(*categoryNamesFile, "category"); err != nil {
+			fmt.Fprintf(stderr, "Error loading -category-names: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	return names, nil
-}
 
-func loadVideoMaps(path string) (*VideoMapLibrary, error) {
-	data, err := os.ReadFile(path)
+	clipRegions, err := buildClipRegions(clipLats, clipLons, clipRadii)
 	if err != nil {
-		return nil, err
+		fmt.Fprintf(stderr, "Invalid clip region: %v\n", err)
+		os.Exit(1)
 	}
-
-	var r io.Reader
-	br := bytes.NewReader(data)
-
-	// Check for zstd magic bytes: 0x28 0xB5 0x2F 0xFD
-	if len(data) > 4 && data[0] == 0x28 && data[1] == 0xb5 && data[2] == 0x2f && data[3] == 0xfd {
-		fmt.Fprintf(os.Stderr, "Detected zstd compression, decompressing...\n")
-		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(0))
-		if err != nil {
-			return nil, fmt.Errorf("zstd init: %w", err)
+	if *clipMode != clipModeInside && *clipMode != clipModeIntersect {
+		fmt.Fprintf(stderr, "Invalid -clip-mode %q (want \"inside\" or \"intersect\")\n", *clipMode)
+		os.Exit(1)
+	}
+	if *emitClipOutline && (len(clipRegions) == 0 || *scenarioPath != "") {
+		fmt.Fprintf(stderr, "-emit-clip-outline needs -clip-lat/-clip-lon, and cannot be used with -scenario\n")
+		os.Exit(1)
+	}
+	adaptiveInner := 0.0 // off
+	if *adaptivePrecision {
+		adaptiveInner = *adaptiveInnerNM
+		if len(clipRegions) == 0 {
+			fmt.Fprintf(stderr, "-adaptive-precision measures distance from the clip centers; it needs -clip-lat/-clip-lon\n")
+			os.Exit(1)
+		}
+		if *adaptiveInnerNM <= 0 {
+			fmt.Fprintf(stderr, "Invalid -adaptive-inner-nm %g (must be > 0)\n", *adaptiveInnerNM)
+			os.Exit(1)
+		}
+		if *adaptiveOuterPrecision < 0 || *adaptiveOuterPrecision > *precision {
+			fmt.Fprintf(stderr, "Invalid -adaptive-outer-precision %d (want 0 to -precision %d)\n", *adaptiveOuterPrecision, *precision)
+			os.Exit(1)
 		}
-		defer zr.Close()
-		r = zr
-	} else {
-		fmt.Fprintf(os.Stderr, "No zstd compression detected, reading raw gob\n")
-		r = br
 	}
+	oo := outputOptions{
+		Compact:       *compact,
+		MinimalFields: *minimalFields,
+		QuantizeBits:  *quantizeBits,
+		LocalOrigin:   localOrigin,
+		CoordOrder:    coordOrder,
+		Format:        *format,
+		WKTPolygons:   *detectPolygons,
 
-	// Try decoding as VideoMapLibrary first (current Vice format)
-	var vmf VideoMapLibrary
-	if err := gob.NewDecoder(r).Decode(&vmf); err != nil {
-		fmt.Fprintf(os.Stderr, "VideoMapLibrary decode failed (%v), trying []VideoMap fallback...\n", err)
+		CoordinateEpsilon: *coordEpsilon,
+		Multiline:         *multiline,
 
-		// Reset reader for retry
-		br = bytes.NewReader(data)
-		if len(data) > 4 && data[0] == 0x28 && data[1] == 0xb5 && data[2] == 0x2f && data[3] == 0xfd {
-			zr, _ := zstd.NewReader(br, zstd.WithDecoderConcurrency(0))
-			defer zr.Close()
-			r = zr
-		} else {
-			r = br
-		}
+		NoScientific:   *noScientific,
+		Decimals:       max(*precision, 0),
+		CompactNumbers: *compactNumbers,
 
-		// Try decoding as just []VideoMap (old format)
-		if err2 := gob.NewDecoder(r).Decode(&vmf.Maps); err2 != nil {
-			return nil, fmt.Errorf("gob decode failed (both formats): library=%v, slice=%v", err, err2)
+		Canonical:      *canonical,
+		RoundTripCheck: *roundTripCheckFlag,
+		Schema:         schema,
+		PostCommand:    *postCommand,
+	}
+	if *wrap {
+		source := *videomapPath
+		if *bundlePath != "" {
+			source = *bundlePath
+		}
+		oo.Wrap = &OutputMetadata{
+			Generator:     "vice-extract",
+			Source:        filepath.Base(source),
+			SourceVersion: *sourceVersion,
+			LocalOrigin:   localOrigin,
+			CoordOrder:    coordOrder,
 		}
 	}
 
-	return &vmf, nil
-}
+	opts := convertOptions{
+		ClipRegions: clipRegions,
+		ClipMode:    *clipMode,
+		Precision:   *precision,
+
+		AdaptiveInnerNM:        adaptiveInner,
+		AdaptiveOuterPrecision: *adaptiveOuterPrecision,
+
+		Winding:           *winding,
+		CoordinateEpsilon: *coordEpsilon,
+		Bearings:          *includeBearings,
+		CumLength:         *includeCumLength,
+		FeatureIds:        *featureIds,
+
+		LabelsAsFeatures:  *labelsAsFeatures,
+		MinStripPoints:    *minStripPoints,
+		ReverseStripOrder: *reverseStripOrder,
+		Centroid:          *includeCentroid,
+		BoundingCircle:    *includeBoundingCircle,
+
+		FeatureRestrictions: *perFeatureRestrictions,
+
+		DetectArcs:        *detectArcs,
+		ArcTolerance:      *arcTolerance,
+		SimplifyTolerance: *simplifyTolerance,
+
+		CategoryTolerances: categoryTolerances,
+
+		CollapseCollinear:  *collapseCollinearFlag,
+		CollinearTolerance: *collinearTolerance,
+		MinPointSpacing:    *maxPointSpacing,
+		GeodesicSpacing:    *geodesicDensify,
+
+		NormalizeLongitude: *normalizeLon,
+		NormalizeNames:     *normalizeNames,
+
+		SlugStrip: *slugStrip,
+
+		ShortNameLength: *shortNameLength,
+		Renames:         renames,
+		IdRemap:         idRemap,
+		NoClip:          parseNameList(*noClipMaps),
+
+		GroupNames:    groupNames,
+		CategoryNames: categoryNames,
+		ZOrders:       zOrders,
+	}
+
+	// Register []string for gob interface decoding
+	// (manifest uses map[string]any which may contain []string values)
+	gob.Register([]string{})
+
+	var bundle *bundleContents
+	if *bundlePath != "" {
+		if *videomapPath != "" || len(manifestPaths) > 0 {
+			fmt.Fprintf(stderr, "-bundle cannot be combined with -videomaps or -manifest\n")
+			os.Exit(1)
+		}
+		var err error
+		bundle, err = readBundle(*bundlePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading bundle: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Bundle %s: videomaps=%s", *bundlePath, bundle.VideoMapsName)
+		if bundle.ManifestName != "" {
+			fmt.Fprintf(stderr, " manifest=%s", bundle.ManifestName)
+		}
+		fmt.Fprintln(stderr)
+	}
+
+	// 1. Load and display manifest(s) if provided
+	var manifestNames map[string]any
+	if bundle != nil && bundle.Manifest != nil {
+		names, err := decodeManifest(bytes.NewReader(bundle.Manifest))
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: Failed to load manifest: %v\n", err)
+		} else {
+			fmt.Fprintf(stderr, "Manifest contains %d map names\n\n", len(names))
+			manifestNames = names
+		}
+	}
+	for _, path := range manifestPaths {
+		names, err := loadManifest(path, *httpTimeout)
+		if err != nil {
+			fmt.Fprintf(stderr, "Warning: Failed to load manifest %s: %v\n", path, err)
+			continue
+		}
+		fmt.Fprintf(stderr, "Manifest %s contains %d map names\n", path, len(names))
+		if manifestNames == nil {
+			manifestNames = names
+			continue
+		}
+		for _, c := range mergeManifest(manifestNames, names) {
+			fmt.Fprintf(stderr, "  WARNING: Manifest %s: '%s' conflicts with an earlier manifest\n", path, c)
+		}
+	}
+	if len(manifestPaths) > 1 && manifestNames != nil {
+		fmt.Fprintf(stderr, "Merged manifests: %d map names\n", len(manifestNames))
+	}
+	if len(manifestPaths) > 0 {
+		fmt.Fprintln(stderr)
+	}
+
+	// 2. Load video map library
+	lo := loadOptions{Format: *inputFormat, MaxDecodeBytes: *maxDecodeBytes, Mmap: *useMmap, Verbose: *verbose, NoFallback: *noFallback,
+		DecodeJobs: *decodeJobs, BufferDecompressed: *decodeBuffer, HTTPTimeout: *httpTimeout, ZstdMaxMemory: uint64(*zstdMaxMemory)}
+	if *zstdDict != "" {
+		dict, err := os.ReadFile(*zstdDict)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading zstd dictionary: %v\n", err)
+			os.Exit(1)
+		}
+		lo.ZstdDicts = [][]byte{dict}
+	}
+	var vmLib *VideoMapLibrary
+	var layout string
+	if bundle != nil {
+		fmt.Fprintf(stderr, "Loading video maps from %s:%s...\n", *bundlePath, bundle.VideoMapsName)
+		vmLib, layout, err = decodeVideoMaps(bundle.VideoMaps, lo)
+	} else {
+		fmt.Fprintf(stderr, "Loading video maps from %s...\n", *videomapPath)
+		vmLib, layout, err = loadVideoMaps(*videomapPath, lo)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading video maps: %v\n", err)
+		os.Exit(1)
+	}
+	if layout == layoutLegacy {
+		fmt.Fprintf(stderr, "\n*** NOTE: Decoded using LEGACY []VideoMap layout ***\n\n")
+	}
+	if *requireFormat != "" && layout != *requireFormat {
+		fmt.Fprintf(stderr, "Error: decoded %s layout but -require-format is %s\n", layout, *requireFormat)
+		os.Exit(1)
+	}
+	fmt.Fprintf(stderr, "Loaded %d total video maps from file\n", len(vmLib.Maps))
+
+	for _, r := range opts.ClipRegions {
+		fmt.Fprintf(stderr, "Clipping to %.1f nm radius around (%.3f, %.3f)\n", r.RadiusNM, r.Lat, r.Lon)
+	}
+	if len(opts.ClipRegions) > 0 && opts.ClipMode == clipModeIntersect {
+		fmt.Fprintf(stderr, "Keeping whole strips with any point inside a clip region (-clip-mode intersect)\n")
+	}
+	if opts.AdaptiveInnerNM > 0 {
+		fmt.Fprintf(stderr, "Coordinate precision: %d decimal places within %g nm of a clip center, %d beyond\n\n",
+			*precision, opts.AdaptiveInnerNM, opts.AdaptiveOuterPrecision)
+	} else {
+		fmt.Fprintf(stderr, "Coordinate precision: %d decimal places\n\n", *precision)
+	}
+
+	if *detectSwapped || *repairSwapped {
+		ref, ok := libraryMedian(vmLib.Maps)
+		if len(opts.ClipRegions) > 0 {
+			ref, ok = Position{Lat: opts.ClipRegions[0].Lat, Lon: opts.ClipRegions[0].Lon}, true
+		}
+		flagged := 0
+		for i, vm := range vmLib.Maps {
+			if !ok || !looksSwapped(vm, ref) {
+				continue
+			}
+			flagged++
+			if *repairSwapped {
+				fmt.Fprintf(stderr, "WARNING: [%d] '%s' looks like it has lat/lon swapped; repairing (-repair-swapped)\n", vm.Id, vm.Name)
+				vmLib.Maps[i] = swapLatLon(vm)
+			} else {
+				fmt.Fprintf(stderr, "WARNING: [%d] '%s' looks like it has lat/lon swapped (repair with -repair-swapped)\n", vm.Id, vm.Name)
+			}
+		}
+		fmt.Fprintf(stderr, "Swapped-coordinate check around (%.3f, %.3f): %d maps flagged\n\n", ref.Lat, ref.Lon, flagged)
+	}
+
+	present := make(map[string]bool, len(vmLib.Maps))
+	for _, vm := range vmLib.Maps {
+		present[vm.Name] = true
+	}
+	for _, name := range sortedKeys(zOrders) {
+		if !present[name] {
+			fmt.Fprintf(stderr, "WARNING: -z-order-file map '%s' NOT FOUND in video map file\n", name)
+		}
+	}
+	if len(renames) > 0 {
+		for _, from := range sortedKeys(renames) {
+			if !present[from] {
+				fmt.Fprintf(stderr, "WARNING: Rename source '%s' NOT FOUND in video map file\n", from)
+			}
+		}
+		fmt.Fprintf(stderr, "Loaded %d display-name renames\n\n", len(renames))
+	}
+	if len(idRemap) > 0 {
+		generated := make(map[string]bool, len(vmLib.Maps))
+		for _, vm := range vmLib.Maps {
+			generated[slugify(vm.Name, opts.SlugStrip)] = true
+		}
+		for _, from := range sortedKeys(idRemap) {
+			if !generated[from] {
+				fmt.Fprintf(stderr, "WARNING: ID remap source '%s' does not match any generated map ID\n", from)
+			}
+		}
+		fmt.Fprintf(stderr, "Loaded %d map ID remaps\n\n", len(idRemap))
+	}
+	if len(opts.ClipRegions) > 0 && len(opts.NoClip) > 0 {
+		for _, name := range sortedKeys(opts.NoClip) {
+			if !present[name] {
+				fmt.Fprintf(stderr, "WARNING: No-clip map '%s' NOT FOUND in video map file\n", name)
+			}
+		}
+		fmt.Fprintf(stderr, "Exempting %d maps from clipping\n\n", len(opts.NoClip))
+	}
+
+	// Canonical list of selectable names, e.g. for -filter autocomplete
+	if *namesOut != "" {
+		names := allMapNames(vmLib.Maps, manifestNames)
+		if _, err := writeJSON(*namesOut, names, false); err != nil {
+			fmt.Fprintf(stderr, "Error writing names: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Wrote %d map names to %s\n\n", len(names), displayPath(*namesOut))
+		if *namesOnly {
+			return
+		}
+	}
+
+	// Scenario mode: per-position outputs replace the filter/sample pipeline
+	if *scenarioPath != "" {
+		if err := runScenario(*scenarioPath, vmLib, opts, *outPath, oo, *failOnSkip); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 3. Build filter set from comma-separated names
+	filterSet := parseNameList(*filterNames)
+	if scenarioOrder != nil {
+		filterSet = make(map[string]bool, len(scenarioOrder))
+		for _, name := range scenarioOrder {
+			filterSet[name] = true
+		}
+	}
+	if len(filterSet) > 0 || len(filterIds) > 0 {
+		fmt.Fprintf(stderr, "Filtering to %d requested maps\n\n", len(filterSet)+len(filterIds))
+	}
+	// listed reports whether vm passes -filter/-filter-vice-id (the union)
+	listed := func(vm VideoMap) bool {
+		return (len(filterSet) == 0 && len(filterIds) == 0) || filterSet[vm.Name] || filterIds[vm.Id]
+	}
+
+	var selectPred selectPredicate
+	if *selectExpr != "" {
+		var err error
+		if selectPred, err = parseSelect(*selectExpr); err != nil {
+			fmt.Fprintf(stderr, "Invalid -select: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Reconnaissance: report the raw extent of the selected maps and stop
+	if *bboxReport {
+		var selected []VideoMap
+		for _, vm := range vmLib.Maps {
+			if listed(vm) && (selectPred == nil || selectPred(selectFieldsOf(vm))) &&
+				(excludeRE == nil || !excludeRE.MatchString(vm.Name)) {
+				selected = append(selected, vm)
+			}
+		}
+		fmt.Fprintf(stderr, "%s", formatBBoxReport(selected))
+		return
+	}
+
+	if *sample > 1 {
+		fmt.Fprintf(stderr, "Sampling every %d maps\n\n", *sample)
+	}
+	if opts.Winding != "" {
+		fmt.Fprintf(stderr, "Normalizing closed strips to %s winding\n\n", opts.Winding)
+	}
+
+	band := mvaBand{Min: *mvaMin, Max: *mvaMax}
+	if band.active() {
+		ceiling := "no ceiling"
+		if band.Max > 0 {
+			ceiling = fmt.Sprintf("%d ft", band.Max)
+		}
+		fmt.Fprintf(stderr, "Keeping MVA maps from %d ft to %s\n\n", band.Min, ceiling)
+	}
+
+	// 4. Select matching maps
+	var selected []VideoMap
+	var decisions, selectedDecisions []*mapDecision // -explain
+	foundSet := make(map[string]bool)
+	foundIds := make(map[int]bool)
+	matched := 0
+	for _, vm := range vmLib.Maps {
+		var d *mapDecision
+		if *explain {
+			d = &mapDecision{Id: vm.Id, Name: vm.Name}
+			decisions = append(decisions, d)
+		}
+
+		// Skip if not in filter set
+		if !listed(vm) {
+			d.exclude("filter: not listed in -filter or -filter-vice-id")
+			continue
+		}
+		switch {
+		case filterSet[vm.Name]:
+			d.note("filter: listed in -filter")
+		case filterIds[vm.Id]:
+			d.note("filter: Id %d listed in -filter-vice-id", vm.Id)
+		default:
+			d.note("filter: none (all maps pass)")
+		}
+		foundSet[vm.Name] = true
+		foundIds[vm.Id] = true
+
+		if selectPred != nil && !selectPred(selectFieldsOf(vm)) {
+			d.exclude("select: -select expression is false")
+			continue
+		}
+		if excludeRE != nil && excludeRE.MatchString(vm.Name) {
+			d.exclude("exclude: name matches -exclude-regex")
+			continue
+		}
+
+		if band.active() && isMVAMap(vm) {
+			alt, ok := parseMVAAltitude(vm.Restriction.Text)
+			switch {
+			case !ok:
+				fmt.Fprintf(stderr, "  WARNING: MVA map '%s': no altitude in restriction text %q, kept\n", vm.Name, vm.Restriction.Text)
+				d.note("mva: no altitude in restriction text, kept")
+			case !band.contains(alt):
+				d.exclude("mva: %d ft outside -mva-min/-mva-max", alt)
+				continue
+			default:
+				d.note("mva: %d ft within band", alt)
+			}
+		}
+
+		// Palette check: the renderer draws unknown colors black
+		if len(allowedColors) > 0 && !slices.Contains(allowedColors, vm.Color) {
+			switch *onBadColor {
+			case "drop":
+				fmt.Fprintf(stderr, "  WARNING: Dropping '%s': color %d not in -allowed-colors\n", vm.Name, vm.Color)
+				d.exclude("color: %d not in -allowed-colors, dropped", vm.Color)
+				continue
+			case "default":
+				fmt.Fprintf(stderr, "  WARNING: Recoloring '%s': color %d -> %d\n", vm.Name, vm.Color, allowedColors[0])
+				d.note("color: %d not in -allowed-colors, recolored to %d", vm.Color, allowedColors[0])
+				vm.Color = allowedColors[0]
+			default:
+				fmt.Fprintf(stderr, "  WARNING: '%s' has color %d not in -allowed-colors\n", vm.Name, vm.Color)
+				d.note("color: %d not in -allowed-colors, kept", vm.Color)
+			}
+		} else if len(allowedColors) > 0 {
+			d.note("color: %d allowed", vm.Color)
+		}
+
+		// Sampling: keep the 1st, (N+1)th, ... map that passed the filter
+		matched++
+		if *sample > 1 && (matched-1)%*sample != 0 {
+			d.exclude("sample: match #%d skipped (every %d)", matched, *sample)
+			continue
+		}
+		selected = append(selected, vm)
+		selectedDecisions = append(selectedDecisions, d)
+	}
+
+	// -scenario-json: the position's order, not the file's
+	if scenarioOrder != nil {
+		rank := make(map[string]int, len(scenarioOrder))
+		for i, name := range slices.Backward(scenarioOrder) {
+			rank[name] = i // first listing wins
+		}
+		perm := make([]int, len(selected))
+		for i := range perm {
+			perm[i] = i
+		}
+		sort.SliceStable(perm, func(a, b int) bool { return rank[selected[perm[a]].Name] < rank[selected[perm[b]].Name] })
+		ordered := make([]VideoMap, len(selected))
+		orderedDecisions := make([]*mapDecision, len(selected))
+		for i, k := range perm {
+			ordered[i], orderedDecisions[i] = selected[k], selectedDecisions[k]
+		}
+		selected, selectedDecisions = ordered, orderedDecisions
+	}
+
+	// Tolerance survey: report the tradeoff and stop without writing
+	if *simplifySweepFlag {
+		fmt.Fprintf(stderr, "%s", formatSimplifySweep(simplifySweep(selected, opts, sweepTolerances), len(opts.CategoryTolerances) > 0))
+		return
+	}
+
+	// Fit a total point budget by raising the simplification tolerance
+	var budget budgetResult
+	if *pointBudget > 0 {
+		var err error
+		budget, err = findBudgetTolerance(selected, opts, *pointBudget)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.SimplifyTolerance = budget.Tolerance
+		fmt.Fprintf(stderr, "Point budget %d: simplification tolerance %.5f nm -> %d points\n\n",
+			*pointBudget, budget.Tolerance, budget.Total)
+	}
+
+	// Fit a target file size by lowering precision (then simplifying)
+	if *targetSizeKB > 0 {
+		res, err := findTargetSize(selected, opts, oo, *targetSizeKB*1024, *targetSizeSimplify)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Precision, opts.SimplifyTolerance = res.Precision, res.Tolerance
+		oo.Decimals = res.Precision
+		fmt.Fprintf(stderr, "Target size %d KB: precision %d, simplification tolerance %.5f nm -> ~%.1f KB\n\n",
+			*targetSizeKB, res.Precision, res.Tolerance, float64(res.Bytes)/1024)
+	}
+
+	// 5. Convert selected maps to our JSON format
+	var outputMaps []OutputVideoMap
+	var outputViceNames []string       // parallel to outputMaps, for -visible-from-scenario
+	var outputDecisions []*mapDecision // parallel to outputMaps, for -dedupe-maps
+	defaultVisibleCount := 0
+	totalPointsBefore := 0
+	totalPointsAfter := 0
+	totalFeaturesBefore := 0
+	totalFeaturesAfter := 0
+	totalInvalidPoints := 0
+	totalCollinearRemoved := 0
+	totalDegenerate := 0
+	largeMaps := 0           // over -warn-large-map-points
+	skippedMaps := 0         // conversion failed (convertMapSafe)
+	var clippedAway []string // had strips, but none inside any clip region
+
+	for i, vm := range selected {
+		// Count before clipping
+		for _, strip := range vm.Lines {
+			totalFeaturesBefore++
+			totalPointsBefore += len(strip)
+		}
+
+		if *normalizeNames {
+			if n := normalizeName(vm.Name); n != vm.Name {
+				fmt.Fprintf(stderr, "  Normalized name [%d] %q -> %q\n", vm.Id, vm.Name, n)
+			}
+		}
+
+		// First N non-empty maps default to visible (unless ranking by points below)
+		isDefaultVisible := *defaultVisibleBy == "order" && defaultVisibleCount < *defaultVisibleN && len(vm.Lines) > 0
+		outMap, stats, err := convertMapSafe(vm, isDefaultVisible, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "  WARNING: Skipping [%d] '%s': %v\n", vm.Id, vm.Name, err)
+			selectedDecisions[i].exclude("skipped: %v", err)
+			skippedMaps++
+			continue
+		}
+		totalInvalidPoints += stats.InvalidPoints
+		totalCollinearRemoved += stats.CollinearRemoved
+		totalDegenerate += stats.Degenerate
+
+		// Count after conversion
+		for _, f := range outMap.Features {
+			totalFeaturesAfter++
+			totalPointsAfter += len(f.Points)
+		}
+
+		// Maps left with no features (fully clipped, or empty in the source)
+		empty := len(outMap.Features) == 0
+		allClipped := empty && stats.ClippedStrips > 0
+		if allClipped {
+			clippedAway = append(clippedAway, vm.Name)
+		}
+		dropped := empty && *emptyMode == "drop"
+		if empty && *emptyMode == "placeholder" {
+			outMap.Disabled = true
+			outMap.DefaultVisible = false
+		}
+		if !dropped {
+			outputMaps = append(outputMaps, outMap)
+			outputViceNames = append(outputViceNames, vm.Name)
+			outputDecisions = append(outputDecisions, selectedDecisions[i])
+			if len(vm.Lines) > 0 && !outMap.Disabled {
+				defaultVisibleCount++
+			}
+		}
+
+		// Statistics
+		if logger != nil {
+			logger.Info("converted map",
+				"map", vm.Name, "viceId", vm.Id,
+				"features", len(outMap.Features), "points", countPoints(outMap),
+				"sourcePoints", countSourcePoints(vm), "clippedStrips", stats.ClippedStrips,
+				"arcs", stats.Arcs, "invalidPoints", stats.InvalidPoints,
+				"collinearRemoved", stats.CollinearRemoved, "shortStrips", stats.ShortStrips,
+				"clippedAway", allClipped, "dropped", dropped, "disabled", outMap.Disabled)
+		} else {
+			fmt.Fprintf(stderr, "  [%3d] %-25s  %5d features, %7d points",
+				vm.Id, vm.Name, len(outMap.Features), countPoints(outMap))
+			if opts.clips(vm.Name) {
+				if origPts := countSourcePoints(vm); origPts > 0 {
+					pct := float64(countPoints(outMap)) / float64(origPts) * 100
+					fmt.Fprintf(stderr, "  (%.0f%% of %d)", pct, origPts)
+				}
+			}
+			if budget.Baseline != nil && budget.Baseline[i] != countPoints(outMap) {
+				fmt.Fprintf(stderr, "  [budget: %d -> %d]", budget.Baseline[i], countPoints(outMap))
+			}
+			if stats.Arcs > 0 {
+				fmt.Fprintf(stderr, "  [%d arcs]", stats.Arcs)
+			}
+			if stats.CollinearRemoved > 0 {
+				fmt.Fprintf(stderr, "  [%d collinear points removed]", stats.CollinearRemoved)
+			}
+			if stats.InvalidPoints > 0 {
+				fmt.Fprintf(stderr, "  [%d NaN/Inf points dropped]", stats.InvalidPoints)
+			}
+			if stats.ShortStrips > 0 {
+				fmt.Fprintf(stderr, "  [%d strips under %d points dropped]", stats.ShortStrips, max(opts.MinStripPoints, 2))
+			}
+			if allClipped {
+				fmt.Fprintf(stderr, "  [clipped away entirely]")
+			}
+			if dropped {
+				fmt.Fprintf(stderr, "  [empty: dropped]")
+			} else if outMap.Disabled {
+				fmt.Fprintf(stderr, "  [empty: disabled placeholder]")
+			}
+			fmt.Fprintln(stderr)
+		}
+
+		// Advisory only: large maps are kept
+		if pts := countPoints(outMap); *warnLargeMap > 0 && pts > *warnLargeMap && !dropped {
+			fmt.Fprintf(stderr, "  WARNING: Large map '%s': %d points (over -warn-large-map-points %d)\n", vm.Name, pts, *warnLargeMap)
+			largeMaps++
+		}
+
+		if d := selectedDecisions[i]; d != nil {
+			switch {
+			case opts.clips(vm.Name):
+				pts := countSourcePoints(vm)
+				d.note("clip: %d of %d points kept; %d strips (%d points) outside every region",
+					pts-stats.ClippedPoints, pts, stats.ClippedStrips, stats.ClippedPoints)
+			case len(opts.ClipRegions) > 0:
+				d.note("clip: exempt (-no-clip-maps)")
+			}
+			if allClipped {
+				d.note("clipped away entirely")
+			}
+			switch {
+			case dropped:
+				d.exclude("empty: no features left (-empty-mode drop)")
+			case outMap.Disabled:
+				d.include("disabled placeholder: no features left")
+			case empty:
+				d.include("empty: no features left")
+			default:
+				d.include("%d features, %d points", len(outMap.Features), countPoints(outMap))
+			}
+		}
+	}
+
+	if totalDegenerate > 0 {
+		fmt.Fprintf(stderr, "WARNING: Removed %d features with fewer than %d points\n", totalDegenerate, max(opts.MinStripPoints, 2))
+	}
+
+	if *dedupe {
+		kept, dups, err := dedupeMaps(outputMaps)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error deduplicating maps: %v\n", err)
+			os.Exit(1)
+		}
+		if len(dups) > 0 {
+			fmt.Fprintf(stderr, "\nDropped %d duplicate maps (-dedupe-maps):\n", len(dups))
+			for _, dup := range dups {
+				m, keep := outputMaps[dup.Index], outputMaps[dup.KeptIndex]
+				fmt.Fprintf(stderr, "  [%d] %s -> same as [%d] %s\n", m.ViceId, m.Name, keep.ViceId, keep.Name)
+				outputDecisions[dup.Index].exclude("duplicate of viceId %d (-dedupe-maps)", keep.ViceId)
+				totalFeaturesAfter -= len(m.Features)
+				totalPointsAfter -= countPoints(m)
+			}
+			maps := make([]OutputVideoMap, len(kept))
+			names := make([]string, len(kept))
+			for j, k := range kept {
+				maps[j], names[j] = outputMaps[k], outputViceNames[k]
+			}
+			outputMaps, outputViceNames = maps, names
+		}
+	}
+
+	if *explain {
+		fmt.Fprintf(stderr, "\n%s", formatExplainReport(decisions))
+	}
+
+	if *defaultVisibleBy == "points" {
+		names := selectDefaultVisibleByPoints(outputMaps, *defaultVisibleN)
+		fmt.Fprintf(stderr, "\nDefault visible (top %d by points): %s\n", *defaultVisibleN, strings.Join(names, ", "))
+	}
+	if scenarioMentioned != nil {
+		fromScenario := 0
+		for i, name := range outputViceNames {
+			if scenarioMentioned[name] && !outputMaps[i].Disabled {
+				outputMaps[i].DefaultVisible = scenarioVisible[name]
+				fromScenario++
+			}
+		}
+		fmt.Fprintf(stderr, "\nDefault visibility from %s for %d of %d maps\n", scenarioSource, fromScenario, len(outputMaps))
+	}
+
+	// Generated geometry, not from the source; added after every
+	// source-map pass so nothing filters or dedupes it
+	if *emitClipOutline {
+		outputMaps = append(outputMaps, clipOutlineMap(opts.ClipRegions, opts.Precision))
+		outputViceNames = append(outputViceNames, "")
+		fmt.Fprintf(stderr, "\nAdded map %q outlining %d clip regions\n", clipOutlineID, len(opts.ClipRegions))
+	}
+
+	// DCB buttons must be distinguishable
+	if collisions := disambiguateShortNames(outputMaps, opts.ShortNameLength); len(collisions) > 0 {
+		for _, c := range collisions {
+			fmt.Fprintf(stderr, "WARNING: ShortName collision: %s\n", c)
+		}
+		if *strictShortNames {
+			fmt.Fprintf(stderr, "Error: %d ShortName collisions (-strict-shortnames)\n", len(collisions))
+			os.Exit(1)
+		}
+	}
+
+	// 6. Report missing maps
+	if len(filterSet) > 0 {
+		for _, name := range sortedKeys(filterSet) {
+			if !foundSet[name] {
+				fmt.Fprintf(stderr, "  WARNING: Requested map '%s' NOT FOUND in video map file\n", name)
+			}
+		}
+	}
+	for _, id := range viceIds {
+		if !foundIds[id] {
+			fmt.Fprintf(stderr, "  WARNING: Requested ViceId %d NOT FOUND in video map file\n", id)
+		}
+	}
+
+	if logger != nil {
+		logger.Info("summary", "maps", len(outputMaps),
+			"features", totalFeaturesAfter, "featuresBefore", totalFeaturesBefore,
+			"points", totalPointsAfter, "pointsBefore", totalPointsBefore,
+			"invalidPoints", totalInvalidPoints, "collinearRemoved", totalCollinearRemoved,
+			"largeMaps", largeMaps, "skippedMaps", skippedMaps, "clippedAway", clippedAway)
+	} else {
+		fmt.Fprintf(stderr, "\nSummary: %d maps, %d features (%d before), %d points (%d before)\n",
+			len(outputMaps), totalFeaturesAfter, totalFeaturesBefore, totalPointsAfter, totalPointsBefore)
+	}
+	if *sample > 1 {
+		fmt.Fprintf(stderr, "Sampled %d of %d matching maps (every %d)\n", len(outputMaps), matched, *sample)
+	}
+	if opts.CollapseCollinear {
+		fmt.Fprintf(stderr, "Collapsed %d collinear points\n", totalCollinearRemoved)
+	}
+	if totalInvalidPoints > 0 {
+		fmt.Fprintf(stderr, "WARNING: Dropped %d NaN/Inf points (strips split at each)\n", totalInvalidPoints)
+	}
+	if len(clippedAway) > 0 {
+		fmt.Fprintf(stderr, "Clipped away entirely (no strips inside any clip region): %d maps: %s\n",
+			len(clippedAway), strings.Join(clippedAway, ", "))
+	}
+	if largeMaps > 0 {
+		fmt.Fprintf(stderr, "WARNING: %d maps over %d points (-warn-large-map-points)\n", largeMaps, *warnLargeMap)
+	}
+	if skippedMaps > 0 {
+		fmt.Fprintf(stderr, "WARNING: Skipped %d maps that failed to convert\n", skippedMaps)
+		if *failOnSkip {
+			fmt.Fprintf(stderr, "Not writing output (-fail-on-skip)\n")
+			os.Exit(1)
+		}
+	}
+
+	if *densityReport {
+		report := formatDensityReport(outputMaps, *densityCell, *densityTop)
+		if *densityOut != "" {
+			if err := writeFileAtomic(*densityOut, report); err != nil {
+				fmt.Fprintf(stderr, "Error writing density report: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(stderr, "Wrote density report to %s\n", *densityOut)
+		} else {
+			fmt.Fprintf(stderr, "\n%s\n", report)
+		}
+	}
+
+	if *reportDupStrips {
+		fmt.Fprintf(stderr, "\n%s\n", formatDuplicateStripReport(outputMaps))
+	}
+
+	if *manifestOut != "" {
+		if _, err := writeJSON(*manifestOut, buildMapIndex(outputMaps), *compact); err != nil {
+			fmt.Fprintf(stderr, "Error writing map index: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Wrote index of %d maps to %s\n", len(outputMaps), displayPath(*manifestOut))
+	}
+	if *legendOut != "" {
+		legend := buildColorLegend(outputMaps)
+		if _, err := writeJSON(*legendOut, legend, *compact); err != nil {
+			fmt.Fprintf(stderr, "Error writing color legend: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Wrote legend of %d colors to %s\n", len(legend), displayPath(*legendOut))
+	}
+
+	// 7. Write output JSON (one file per category into the -out directory when splitting)
+	if *geojsonPerMap {
+		if err := writeGeoJSONPerMap(*outPath, outputMaps, oo); err != nil {
+			fmt.Fprintf(stderr, "Error writing GeoJSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *splitByCategory {
+		if err := writeCategorySplit(*outPath, outputMaps, oo); err != nil {
+			fmt.Fprintf(stderr, "Error writing category split: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *sincePath != "" {
+		prev, prevIDs, err := loadSinceHashes(*sincePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -since: %v\n", err)
+			os.Exit(1)
+		}
+		delta, err := diffSince(outputMaps, prev, prevIDs)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error comparing with -since: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Since %s: %d new, %d changed, %d unchanged (skipped), %d removed\n",
+			*sincePath, delta.New, delta.Changed, delta.Unchanged, len(delta.Removed))
+		outputMaps, oo.Removed = delta.Maps, delta.Removed
+	}
+	if *mergeInto != "" {
+		oo.MergeBase, err = loadMergeBase(*mergeInto)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error loading -merge-into: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Merging into %d existing maps from %s\n", len(oo.MergeBase), *mergeInto)
+	}
+	n, err := writeMaps(*outPath, outputMaps, oo)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(stderr, "Wrote %s (%.2f MB)\n", displayPath(*outPath), float64(n)/1024/1024)
+
+	// 8. Optional second, compact copy from the same converted maps
+	if *outCompactPath != "" {
+		compactOO := oo
+		compactOO.Compact = true
+		n, err := writeMaps(*outCompactPath, outputMaps, compactOO)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error writing compact output: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(stderr, "Wrote %s (%.2f MB, compact)\n", displayPath(*outCompactPath), float64(n)/1024/1024)
+	}
+
+	if *summaryJSON {
+		summary := runSummary{
+			Maps:           len(outputMaps),
+			Features:       totalFeaturesAfter,
+			FeaturesBefore: totalFeaturesBefore,
+			Points:         totalPointsAfter,
+			PointsBefore:   totalPointsBefore,
+			Dropped:        totalInvalidPoints,
+			LargeMaps:      largeMaps,
+			Skipped:        skippedMaps,
+			OutFile:        *outPath,
+			Bytes:          n,
+		}
+		if _, err := writeJSON("-", summary, true); err != nil {
+			fmt.Fprintf(stderr, "Error writing summary: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// runSummary is the -summary-json result printed to stdout: the numbers of
+// the text summary plus the main output file
+type runSummary struct {
+	Maps           int    `json:"maps"`
+	Features       int    `json:"features"`
+	FeaturesBefore int    `json:"featuresBefore"`
+	Points         int    `json:"points"`
+	PointsBefore   int    `json:"pointsBefore"`
+	Dropped        int    `json:"dropped"`   // NaN/Inf points
+	LargeMaps      int    `json:"largeMaps"` // over -warn-large-map-points
+	Skipped        int    `json:"skipped"`   // failed to convert
+	OutFile        string `json:"outFile"`
+	Bytes          int    `json:"bytes"`
+}
+
+// outputOptions controls the shape and formatting of written map files
+type outputOptions struct {
+	Compact       bool            // no indentation
+	MinimalFields bool            // write MinimalVideoMap instead of OutputVideoMap
+	Wrap          *OutputMetadata // non-nil: write a WrappedOutput object instead of a bare array
+	QuantizeBits  int             // >0: integer coords on a per-file grid (requires Wrap)
+	LocalOrigin   *Position       // non-nil: nm offsets from this origin (requires Wrap)
+	CoordOrder    string          // non-empty: Coordinates pairs in this order (-coord-format array)
+	// Multiline folds each map's lines into one "multiline" feature
+	Multiline bool
+	// NoScientific writes the converted maps' Positions with Decimals
+	// fixed places, trimming trailing zeros under CompactNumbers
+	NoScientific   bool
+	Decimals       int
+	CompactNumbers bool
+	// Format is "json", "wkt" (formatWKT lines, closed strips as polygons
+	// under WKTPolygons), or "html" (formatHTMLPreview page)
+	Format      string
+	WKTPolygons bool
+	// CoordinateEpsilon is the samePoint tolerance in nm for WKTPolygons
+	CoordinateEpsilon float64
+	// Canonical writes canonicalJSON: sorted keys, fixed number formatting
+	Canonical bool
+	// RoundTripCheck re-reads each written file and verifies it
+	// re-serializes identically (-round-trip-check)
+	RoundTripCheck bool
+	// MergeBase holds existing maps (-merge-into) that the converted maps
+	// are merged into by ID
+	MergeBase []json.RawMessage
+	// Schema, if set, must accept every written file (-schema)
+	Schema *jsonSchema
+	// PostCommand, if set, filters each rendered file through an external
+	// program before it is written (-post-command)
+	PostCommand string
+	// Removed lists map IDs deleted since a previous run (-since); written
+	// in the Wrap envelope
+	Removed []string
+}
+
+// maxSchemaViolations caps how many -schema violations an error lists
+const maxSchemaViolations = 20
+
+// writeMaps serializes converted maps per oo and writes them to path.
+// Returns the number of bytes written.
+func writeMaps(path string, maps []OutputVideoMap, oo outputOptions) (int, error) {
+	data, err := renderMaps(maps, oo)
+	if err != nil {
+		return 0, err
+	}
+	if oo.PostCommand != "" {
+		if data, err = runPostCommand(oo.PostCommand, data); err != nil {
+			return 0, err
+		}
+	}
+	// Validate before writing so a failing file never replaces the last one
+	if oo.Schema != nil {
+		if err := checkSchema(data, oo.Schema); err != nil {
+			return 0, err
+		}
+	}
+	if path == "-" && (oo.Format == "" || oo.Format == "json") {
+		data = append(data, '\n')
+	}
+	n, err := writeOutput(path, data)
+	if err == nil && oo.RoundTripCheck && path != "-" {
+		err = roundTripCheck(path, oo)
+	}
+	return n, err
+}
+
+// checkSchema validates written output against a -schema, listing up to
+// maxSchemaViolations failing paths
+func checkSchema(data []byte, schema *jsonSchema) error {
+	violations, err := schema.validate(data)
+	if err != nil {
+		return fmt.Errorf("schema check: %w", err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	shown := violations[:min(len(violations), maxSchemaViolations)]
+	msg := fmt.Sprintf("output violates -schema (%d violations):\n  %s", len(violations), strings.Join(shown, "\n  "))
+	if len(violations) > len(shown) {
+		msg += fmt.Sprintf("\n  ... and %d more", len(violations)-len(shown))
+	}
+	return errors.New(msg)
+}
+
+// renderMaps returns the file contents writeMaps writes for maps
+func renderMaps(maps []OutputVideoMap, oo outputOptions) ([]byte, error) {
+	switch oo.Format {
+	case "wkt":
+		return formatWKT(maps, oo.WKTPolygons, oo.CoordinateEpsilon), nil
+	case "html":
+		return formatHTMLPreview(maps)
+	}
+
+	if oo.Multiline {
+		maps = multilineMaps(maps)
+	}
+	if oo.LocalOrigin != nil {
+		maps = localizeMaps(maps, *oo.LocalOrigin)
+	}
+	if oo.CoordOrder != "" {
+		maps = arrayMaps(maps, oo.CoordOrder)
+	}
+
+	var transform *QuantizeTransform
+	if oo.QuantizeBits > 0 {
+		t := newQuantizeTransform(maps, oo.QuantizeBits)
+		transform = &t
+		maps = quantizeMaps(maps, t)
+	}
+	if oo.NoScientific {
+		maps = formatMaps(maps, oo.positionFormat())
+	}
+
+	var v any = maps
+	if oo.MinimalFields {
+		minimal := make([]MinimalVideoMap, len(maps))
+		for i, m := range maps {
+			minimal[i] = MinimalVideoMap{
+				ID:             m.ID,
+				Name:           m.Name,
+				ShortName:      m.ShortName,
+				DefaultVisible: m.DefaultVisible,
+				GroupName:      m.GroupName,
+				CategoryName:   m.CategoryName,
+				Features:       m.Features,
+				Disabled:       m.Disabled,
+				Centroid:       m.Centroid,
+				BoundingCircle: m.BoundingCircle,
+				ZOrder:         m.ZOrder,
+			}
+		}
+		v = minimal
+	}
+	if oo.MergeBase != nil {
+		merged, added, replaced, err := mergeMaps(oo.MergeBase, v)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(stderr, "Merged %d maps: %d added, %d replaced, %d total\n",
+			len(maps), added, replaced, len(merged))
+		v = merged
+	}
+	if oo.Wrap != nil {
+		v = WrappedOutput{Metadata: *oo.Wrap, Transform: transform, Maps: v, Removed: oo.Removed}
+	}
+	if oo.Canonical {
+		return canonicalJSON(v, oo.Compact)
+	}
+	return marshalJSON(v, oo.Compact)
+}
+
+// positionFormat returns the Position formatting oo asks for
+func (oo outputOptions) positionFormat() positionFormat {
+	if !oo.NoScientific {
+		return positionFormat{}
+	}
+	return positionFormat{fixed: true, decimals: oo.Decimals, trim: oo.CompactNumbers}
+}
+
+// stripDegenerateFeatures drops point-based features with fewer than
+// minPoints points, so no consumer sees a degenerate line whatever the
+// conversion steps did. Arc and label features carry no points and are
+// kept. m's Features are not modified; the number removed is returned.
+func stripDegenerateFeatures(m OutputVideoMap, minPoints int) (OutputVideoMap, int) {
+	degenerate := func(f VideoMapFeature) bool {
+		return f.Arc == nil && f.Position == nil && len(f.Points) < minPoints
+	}
+	if !slices.ContainsFunc(m.Features, degenerate) {
+		return m, 0
+	}
+	kept := make([]VideoMapFeature, 0, len(m.Features))
+	for _, f := range m.Features {
+		if !degenerate(f) {
+			kept = append(kept, f)
+		}
+	}
+	removed := len(m.Features) - len(kept)
+	m.Features = kept
+	return m, removed
+}
+
+// roundTripCheck reads a written map file back into the output types,
+// re-serializes it, and requires the result to match the file byte for byte
+// apart from insignificant whitespace. Catches fields that don't survive
+// encoding (lossy floats, tags that disagree between types, ...).
+func roundTripCheck(path string, oo outputOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var maps any = &[]OutputVideoMap{}
+	if oo.MinimalFields {
+		maps = &[]MinimalVideoMap{}
+	}
+	v := maps
+	if oo.Wrap != nil {
+		v = &WrappedOutput{Maps: maps}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("round-trip check: read back %s: %w", path, err)
+	}
+	if pf := oo.positionFormat(); pf.fixed {
+		switch m := maps.(type) {
+		case *[]OutputVideoMap:
+			*m = formatMaps(*m, pf)
+		case *[]MinimalVideoMap:
+			for i := range *m {
+				(*m)[i].Features = formatFeatures((*m)[i].Features, pf)
+				(*m)[i].Centroid = formatPosition((*m)[i].Centroid, pf)
+			}
+		}
+	}
+	marshal := json.Marshal
+	if oo.Canonical {
+		marshal = func(v any) ([]byte, error) { return canonicalJSON(v, true) }
+	}
+	again, err := marshal(v)
+	if err != nil {
+		return fmt.Errorf("round-trip check: re-marshal %s: %w", path, err)
+	}
+	var written bytes.Buffer
+	if err := json.Compact(&written, data); err != nil {
+		return fmt.Errorf("round-trip check: %s: %w", path, err)
+	}
+	if !bytes.Equal(written.Bytes(), again) {
+		i := 0
+		for i < len(again) && i < written.Len() && again[i] == written.Bytes()[i] {
+			i++
+		}
+		from := max(0, i-40)
+		return fmt.Errorf("round-trip check: %s differs at compact offset %d:\n  written: %s\n  re-read: %s",
+			path, i, written.Bytes()[from:min(written.Len(), i+40)], again[from:min(len(again), i+40)])
+	}
+	return nil
+}
+
+// categoryIndexEntry describes one file written by -split-by-category
+type categoryIndexEntry struct {
+	Category int    `json:"category"`
+	File     string `json:"file"`
+	Maps     int    `json:"maps"`
+}
+
+// mapIndexEntry is one map's line in the -manifest-out index: enough to
+// build map menus without loading geometry
+type mapIndexEntry struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ShortName      string `json:"shortName"`
+	DefaultVisible bool   `json:"defaultVisible"`
+	Group          int    `json:"group"`
+	Category       int    `json:"category"`
+	GroupName      string `json:"groupName,omitempty"`
+	CategoryName   string `json:"categoryName,omitempty"`
+	Points         int    `json:"points"`
+}
+
+// buildMapIndex lists maps in output order for -manifest-out
+func buildMapIndex(maps []OutputVideoMap) []mapIndexEntry {
+	index := make([]mapIndexEntry, len(maps))
+	for i, m := range maps {
+		index[i] = mapIndexEntry{
+			ID:             m.ID,
+			Name:           m.Name,
+			ShortName:      m.ShortName,
+			DefaultVisible: m.DefaultVisible,
+			Group:          m.Group,
+			Category:       m.Category,
+			GroupName:      m.GroupName,
+			CategoryName:   m.CategoryName,
+			Points:         countPoints(m),
+		}
+	}
+	return index
+}
+
+// legendEntry is one color's line in the -legend-out legend
+type legendEntry struct {
+	Color          int    `json:"color"`
+	Count          int    `json:"count"`          // maps with this color
+	ExampleMapName string `json:"exampleMapName"` // first such map in output order
+}
+
+// buildColorLegend summarizes the distinct map Colors, ascending
+func buildColorLegend(maps []OutputVideoMap) []legendEntry {
+	byColor := make(map[int]*legendEntry)
+	for _, m := range maps {
+		if e, ok := byColor[m.Color]; ok {
+			e.Count++
+			continue
+		}
+		byColor[m.Color] = &legendEntry{Color: m.Color, Count: 1, ExampleMapName: m.Name}
+	}
+	legend := make([]legendEntry, 0, len(byColor))
+	for _, e := range byColor {
+		legend = append(legend, *e)
+	}
+	sort.Slice(legend, func(i, j int) bool { return legend[i].Color < legend[j].Color })
+	return legend
+}
+
+// writeCategorySplit writes category-<n>.json per distinct Category into
+// outDir (maps keep their relative order) plus an index.json listing them.
+func writeCategorySplit(outDir string, maps []OutputVideoMap, oo outputOptions) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	byCategory := make(map[int][]OutputVideoMap)
+	for _, m := range maps {
+		byCategory[m.Category] = append(byCategory[m.Category], m)
+	}
+	categories := make([]int, 0, len(byCategory))
+	for c := range byCategory {
+		categories = append(categories, c)
+	}
+	sort.Ints(categories)
+
+	index := make([]categoryIndexEntry, 0, len(categories))
+	for _, c := range categories {
+		file := fmt.Sprintf("category-%d.json", c)
+		n, err := writeMaps(filepath.Join(outDir, file), byCategory[c], oo)
+		if err != nil {
+			return fmt.Errorf("category %d: %w", c, err)
+		}
+		fmt.Fprintf(stderr, "  category %3d: %3d maps -> %s (%.2f MB)\n", c, len(byCategory[c]), file, float64(n)/1024/1024)
+		index = append(index, categoryIndexEntry{Category: c, File: file, Maps: len(byCategory[c])})
+	}
+	indexPath := filepath.Join(outDir, "index.json")
+	if _, err := writeJSON(indexPath, index, oo.Compact); err != nil {
+		return fmt.Errorf("index: %w", err)
+	}
+	fmt.Fprintf(stderr, "Wrote %d category files and %s\n", len(categories), indexPath)
+	return nil
+}
+
+// writeJSON marshals v (indented unless compact) and writes it to path, or
+// to stdout if path is "-". Returns the number of bytes written.
+func writeJSON(path string, v any, compact bool) (int, error) {
+	data, err := marshalJSON(v, compact)
+	if err != nil {
+		return 0, err
+	}
+	if path == "-" {
+		data = append(data, '\n')
+	}
+	return writeOutput(path, data)
+}
+
+// marshalJSON encodes v compactly or with two-space indentation
+func marshalJSON(v any, compact bool) ([]byte, error) {
+	var data []byte
+	var err error
+	if compact {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal JSON: %w", err)
+	}
+	return data, nil
+}
+
+// writeOutput writes data to path atomically, or to stdout for "-"
+func writeOutput(path string, data []byte) (int, error) {
+	if path == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// displayPath names an output path for log messages
+func displayPath(path string) string {
+	if path == "-" {
+		return "<stdout>"
+	}
+	return path
+}
+
+// writeFileAtomic writes data to a temp file in path's directory and renames
+// it over path, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// stringList is a repeatable string flag (e.g. -manifest a.gob -manifest b.gob)
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// floatList is a repeatable float64 flag (e.g. -clip-lat 37.5 -clip-lat 38.9)
+type floatList []float64
+
+func (l *floatList) String() string {
+	parts := make([]string, len(*l))
+	for i, v := range *l {
+		parts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *floatList) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, v)
+	return nil
+}
+
+// buildClipRegions pairs up repeated -clip-lat/-clip-lon values. Radii may
+// be given once (shared by all regions), once per region, or not at all.
+func buildClipRegions(lats, lons, radii floatList) ([]clipRegion, error) {
+	if len(lats) != len(lons) {
+		return nil, fmt.Errorf("%d -clip-lat values but %d -clip-lon values", len(lats), len(lons))
+	}
+	if len(radii) > 1 && len(radii) != len(lats) {
+		return nil, fmt.Errorf("%d -clip-radius values for %d regions (give one, or one per region)", len(radii), len(lats))
+	}
+	regions := make([]clipRegion, len(lats))
+	for i := range lats {
+		radius := 80.0
+		switch len(radii) {
+		case 1:
+			radius = radii[0]
+		case len(lats):
+			radius = radii[i]
+		}
+		regions[i] = clipRegion{Lat: lats[i], Lon: lons[i], RadiusNM: radius}
+	}
+	return regions, nil
+}
+
+// parseNameList splits a comma-separated list of map names into a set
+func parseNameList(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// parseIntList parses a comma-separated list of integers
+func parseIntList(list string) ([]int, error) {
+	var out []int
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// parseFloatList parses a comma-separated list of numbers
+func parseFloatList(list string) ([]float64, error) {
+	var out []float64
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// loadStringMap reads a JSON object of string -> string (e.g. a rename file)
+func loadStringMap(path string) (map[string]string, error) {
+	var m map[string]string
+	if err := loadJSONConfig(path, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadCategoryTolerances reads a JSON object of map Category -> RDP
+// tolerance in nm, e.g. {"0": 0.05, "3": 0.005}
+func loadCategoryTolerances(path string) (map[int]float64, error) {
+	tolerances, err := loadIntKeyed[float64](path, "category")
+	if err != nil {
+		return nil, err
+	}
+	for category, tol := range tolerances {
+		if tol < 0 {
+			return nil, fmt.Errorf("%s: category %d tolerance %g is negative", path, category, tol)
+		}
+	}
+	return tolerances, nil
+}
+
+// loadNameTable reads a JSON object of Group or Category number -> display
+// name, e.g. {"0": "Geography", "3": "MVA"}
+func loadNameTable(path, what string) (map[int]string, error) {
+	return loadIntKeyed[string](path, what)
+}
+
+// loadIntKeyed reads a JSON object whose keys are integers (what names
+// them in errors)
+func loadIntKeyed[V any](path, what string) (map[int]V, error) {
+	var raw map[string]V
+	if err := loadJSONConfig(path, &raw); err != nil {
+		return nil, err
+	}
+	out := make(map[int]V, len(raw))
+	for k, v := range raw {
+		n, err := strconv.Atoi(strings.TrimSpace(k))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s %q is not an integer", path, what, k)
+		}
+		out[n] = v
+	}
+	return out, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// selectDefaultVisibleByPoints marks the n non-empty maps with the most
+// points as default-visible (ties keep file order) and clears the rest.
+// Returns the selected names in output order.
+func selectDefaultVisibleByPoints(maps []OutputVideoMap, n int) []string {
+	idx := make([]int, len(maps))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return countPoints(maps[idx[a]]) > countPoints(maps[idx[b]])
+	})
+
+	selected := make(map[int]bool, n)
+	for _, i := range idx {
+		if len(selected) >= n || countPoints(maps[i]) == 0 {
+			break
+		}
+		selected[i] = true
+	}
+
+	var names []string
+	for i := range maps {
+		maps[i].DefaultVisible = selected[i]
+		if selected[i] {
+			names = append(names, maps[i].Name)
+		}
+	}
+	return names
+}
+
+func countPoints(m OutputVideoMap) int {
+	n := 0
+	for _, f := range m.Features {
+		n += len(f.Points)
+	}
+	return n
+}
+
+// allMapNames lists each map name once in videomaps order, followed by
+// names only the manifest knows (sorted; the manifest is an unordered set)
+func allMapNames(maps []VideoMap, manifest map[string]any) []string {
+	seen := make(map[string]bool, len(maps))
+	names := make([]string, 0, len(maps))
+	for _, vm := range maps {
+		if !seen[vm.Name] {
+			seen[vm.Name] = true
+			names = append(names, vm.Name)
+		}
+	}
+	for _, name := range sortedKeys(manifest) {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// countSourcePoints totals the points in a source map, before conversion
+func countSourcePoints(vm VideoMap) int {
+	n := 0
+	for _, strip := range vm.Lines {
+		n += len(strip)
+	}
+	return n
+}
+
+// ──────────────────────────────────────────────────────────────────────
+// Loading Vice binary formats
+// ──────────────────────────────────────────────────────────────────────
+
+// mergeManifest adds from's entries to into. The manifest is keyed by map
+// name; a name whose entry differs between the two keeps into's value and
+// is returned (sorted) as a conflict.
+func mergeManifest(into, from map[string]any) []string {
+	var conflicts []string
+	for _, name := range sortedKeys(from) {
+		existing, ok := into[name]
+		if !ok {
+			into[name] = from[name]
+		} else if !reflect.DeepEqual(existing, from[name]) {
+			conflicts = append(conflicts, name)
+		}
+	}
+	return conflicts
+}
+
+// loadManifest reads a manifest file, or fetches it if path is a URL
+func loadManifest(path string, httpTimeout time.Duration) (map[string]any, error) {
+	if isURL(path) {
+		data, err := fetchURL(path, httpTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return decodeManifest(bytes.NewReader(data))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeManifest(f)
+}
+
+func decodeManifest(r io.Reader) (map[string]any, error) {
+	var names map[string]any
+	if err := gob.NewDecoder(r).Decode(&names); err != nil {
+		return nil, fmt.Errorf("gob decode manifest: %w", err)
+	}
+	return names, nil
+}
+
+// inputFormats are the accepted -input-format values
+var inputFormats = []string{"auto", "zstd", "gzip", "gob"}
+
+// loadOptions controls how loadVideoMaps reads and decodes its input
+type loadOptions struct {
+	Format    string   // one of inputFormats; "auto" sniffs the zstd magic bytes
+	ZstdDicts [][]byte // dictionaries for dictionary-compressed zstd streams
+	// MaxDecodeBytes caps the (decompressed) bytes the gob decoder may read,
+	// so a corrupt or hostile length prefix can't exhaust memory. 0 = no cap.
+	MaxDecodeBytes int64
+	// Mmap maps the input file instead of reading it into memory (-mmap)
+	Mmap bool
+	// HTTPTimeout bounds fetching a URL input (0 = no limit)
+	HTTPTimeout time.Duration
+	// Verbose logs how many decompressed bytes each decode pass read
+	Verbose bool
+	// NoFallback skips the legacy []VideoMap retry, so a failed library
+	// decode returns its own error (-no-fallback)
+	NoFallback bool
+	// DecodeJobs is the zstd decoder concurrency (0 = one per CPU)
+	DecodeJobs int
+	// ZstdMaxMemory caps the zstd window size, and so the decoder's
+	// memory (0 = library default)
+	ZstdMaxMemory uint64
+	// BufferDecompressed decompresses the whole input into memory before
+	// gob decoding, so gob never waits on the decompressor and a legacy
+	// fallback doesn't decompress a second time (-decode-buffer)
+	BufferDecompressed bool
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	R io.Reader
+	N int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.R.Read(p)
+	c.N += int64(n)
+	return n, err
+}
+
+// minPlausibleStreamBytes is the smallest decompressed stream worth calling
+// normal: an empty VideoMapLibrary already takes a few hundred bytes of gob
+// type descriptors
+const minPlausibleStreamBytes = 128
+
+// logStreamLength reports (for -verbose) how much decompressed data a
+// decode pass consumed. The gob decoder buffers, so this is what it read,
+// not necessarily where it stopped.
+func logStreamLength(pass, format string, compressed int, cr *countingReader, err error) {
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	fmt.Fprintf(stderr, "  %s decode %s: read %d bytes of %s stream (input %d bytes)\n", pass, status, cr.N, format, compressed)
+	if cr.N < minPlausibleStreamBytes {
+		fmt.Fprintf(stderr, "  WARNING: decompressed stream is only %d bytes; input is likely truncated or not a videomap file\n", cr.N)
+	}
+}
+
+var errDecodeLimit = errors.New("input exceeds -max-decode-bytes")
+
+// decodeGob decodes one gob value from r, reading at most max bytes
+// (0 = unlimited)
+func decodeGob(r io.Reader, v any, max int64) error {
+	if max <= 0 {
+		return gob.NewDecoder(r).Decode(v)
+	}
+	lr := &io.LimitedReader{R: r, N: max}
+	if err := gob.NewDecoder(lr).Decode(v); err != nil {
+		if lr.N <= 0 {
+			return fmt.Errorf("%w (%d bytes)", errDecodeLimit, max)
+		}
+		return err
+	}
+	return nil
+}
+
+// Gob layouts loadVideoMaps can decode, reported so callers can tell a
+// fallback decode from the expected one
+const (
+	layoutLibrary = "library" // current Vice VideoMapLibrary struct
+	layoutLegacy  = "legacy"  // old bare []VideoMap
+)
+
+// loadVideoMaps decodes a video map file, trying the current
+// VideoMapLibrary layout first and the legacy []VideoMap layout second.
+// Returns the library and which layout matched.
+func loadVideoMaps(path string, lo loadOptions) (*VideoMapLibrary, string, error) {
+	if isURL(path) {
+		data, err := fetchURL(path, lo.HTTPTimeout)
+		if err != nil {
+			return nil, "", err
+		}
+		return decodeVideoMaps(data, lo)
+	}
+	if lo.Mmap {
+		data, unmap, err := mmapFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		// Both decode passes read the same mapping; decoded maps don't
+		// reference it, so it can go once decoding is done
+		defer unmap()
+		return decodeVideoMaps(data, lo)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return decodeVideoMaps(data, lo)
+}
+
+// decodeVideoMaps is loadVideoMaps for an in-memory (possibly compressed) file
+func decodeVideoMaps(data []byte, lo loadOptions) (*VideoMapLibrary, string, error) {
+	if lo.MaxDecodeBytes > 0 && int64(len(data)) > lo.MaxDecodeBytes {
+		return nil, "", fmt.Errorf("%w: file is %d bytes, limit %d", errDecodeLimit, len(data), lo.MaxDecodeBytes)
+	}
+	format := lo.Format
+	if format == "auto" {
+		// Check for zstd magic bytes: 0x28 0xB5 0x2F 0xFD
+		if len(data) > 4 && data[0] == 0x28 && data[1] == 0xb5 && data[2] == 0x2f && data[3] == 0xfd {
+			fmt.Fprintf(stderr, "Detected zstd compression, decompressing...\n")
+			format = "zstd"
+		} else {
+			fmt.Fprintf(stderr, "No zstd compression detected, reading raw gob\n")
+			format = "gob"
+		}
+	} else {
+		fmt.Fprintf(stderr, "Input format forced to %s\n", format)
+	}
+
+	if lo.BufferDecompressed && format != "gob" {
+		buf, err := decompressAll(data, format, lo)
+		if err != nil {
+			return nil, "", err
+		}
+		data, format = buf, "gob"
+	}
+
+	r, closeFn, err := newDecompressor(data, format, lo)
+	if err != nil {
+		return nil, "", err
+	}
+	defer closeFn()
+
+	// Try decoding as VideoMapLibrary first (current Vice format)
+	var vmf VideoMapLibrary
+	cr := &countingReader{R: r}
+	err = decodeGob(cr, &vmf, lo.MaxDecodeBytes)
+	if lo.Verbose {
+		logStreamLength(layoutLibrary, format, len(data), cr, err)
+	}
+	if err != nil {
+		if errors.Is(err, errDecodeLimit) {
+			return nil, "", err
+		}
+		if zerr := zstdMemoryError(err, lo); zerr != nil {
+			return nil, "", zerr
+		}
+		if errors.Is(err, zstd.ErrUnknownDictionary) {
+			if len(lo.ZstdDicts) == 0 {
+				return nil, "", fmt.Errorf("input is compressed with a zstd dictionary; supply it with -zstd-dict")
+			}
+			return nil, "", fmt.Errorf("input needs a different zstd dictionary than the one supplied: %w", err)
+		}
+		if lo.NoFallback {
+			return nil, "", fmt.Errorf("VideoMapLibrary decode failed: %w", err)
+		}
+		fmt.Fprintf(stderr, "VideoMapLibrary decode failed (%v), trying []VideoMap fallback...\n", err)
+
+		// Reset reader for retry
+		r, closeRetry, initErr := newDecompressor(data, format, lo)
+		if initErr != nil {
+			return nil, "", initErr
+		}
+		defer closeRetry()
+
+		// Try decoding as just []VideoMap (old format)
+		cr := &countingReader{R: r}
+		err2 := decodeGob(cr, &vmf.Maps, lo.MaxDecodeBytes)
+		if lo.Verbose {
+			logStreamLength(layoutLegacy, format, len(data), cr, err2)
+		}
+		if err2 != nil {
+			if errors.Is(err2, errDecodeLimit) {
+				return nil, "", err2
+			}
+			return nil, "", fmt.Errorf("gob decode failed (both formats): library=%v, slice=%v", err, err2)
+		}
+		return &vmf, layoutLegacy, nil
+	}
+
+	return &vmf, layoutLibrary, nil
+}
+
+// decompressAll returns data decompressed per format, honoring
+// lo.MaxDecodeBytes
+func decompressAll(data []byte, format string, lo loadOptions) ([]byte, error) {
+	r, closeFn, err := newDecompressor(data, format, lo)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	if lo.MaxDecodeBytes > 0 {
+		r = io.LimitReader(r, lo.MaxDecodeBytes+1)
+	}
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		if zerr := zstdMemoryError(err, lo); zerr != nil {
+			return nil, zerr
+		}
+		if errors.Is(err, zstd.ErrUnknownDictionary) {
+			return nil, fmt.Errorf("input is compressed with a zstd dictionary; supply it with -zstd-dict")
+		}
+		return nil, fmt.Errorf("decompress %s: %w", format, err)
+	}
+	if lo.MaxDecodeBytes > 0 && int64(len(buf)) > lo.MaxDecodeBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", errDecodeLimit, lo.MaxDecodeBytes)
+	}
+	if lo.Verbose {
+		fmt.Fprintf(stderr, "  decompressed %d bytes of %s input to %d bytes\n", len(data), format, len(buf))
+	}
+	return buf, nil
+}
+
+// zstdMemoryError returns a readable error if err is the zstd decoder
+// refusing a frame whose window exceeds lo.ZstdMaxMemory, and nil otherwise
+func zstdMemoryError(err error, lo loadOptions) error {
+	if !errors.Is(err, zstd.ErrWindowSizeExceeded) && !errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil
+	}
+	if lo.ZstdMaxMemory == 0 {
+		return fmt.Errorf("zstd: %w", err)
+	}
+	return fmt.Errorf("input needs more zstd decoder memory than -zstd-max-memory %d allows: %w", lo.ZstdMaxMemory, err)
+}
+
+// newDecompressor returns a reader over data decompressed per format
+// ("zstd", "gzip", or "gob" for uncompressed) and a func to release it.
+func newDecompressor(data []byte, format string, lo loadOptions) (io.Reader, func(), error) {
+	br := bytes.NewReader(data)
+	switch format {
+	case "zstd":
+		zopts := []zstd.DOption{zstd.WithDecoderConcurrency(lo.DecodeJobs)}
+		if lo.ZstdMaxMemory > 0 {
+			zopts = append(zopts, zstd.WithDecoderMaxMemory(lo.ZstdMaxMemory))
+		}
+		if len(lo.ZstdDicts) > 0 {
+			zopts = append(zopts, zstd.WithDecoderDicts(lo.ZstdDicts...))
+		}
+		zr, err := zstd.NewReader(br, zopts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("zstd init: %w", err)
+		}
+		return zr, zr.Close, nil
+	case "gzip":
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gzip init: %w", err)
+		}
+		return gr, func() { gr.Close() }, nil
+	case "gob":
+		return br, func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown input format %q", format)
+}
 
 // ──────────────────────────────────────────────────────────────────────
 // Conversion to our JSON format
 // ──────────────────────────────────────────────────────────────────────
 
-func convertMap(vm VideoMap, defaultVisible bool, doClip bool, clipLat, clipLon, clipRadius float64, precision int) OutputVideoMap {
-	id := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(vm.Name, " ", "-"), "/", "-"))
-	shortName := generateShortName(vm.Name)
+// convertStats holds per-map diagnostics collected during conversion
+type convertStats struct {
+	InvalidPoints int // NaN/Inf points dropped (strip split at each one)
+	Arcs          int // strips emitted as arc features (-detect-arcs)
+	ClippedStrips int // strips dropped for leaving every clip region
+	ShortStrips   int // strips dropped for having fewer than MinStripPoints
+	Degenerate    int // features left with fewer than MinStripPoints points
+	ClippedPoints int // points in those strips
+	// CollinearRemoved counts points dropped by -collapse-collinear
+	CollinearRemoved int
+}
 
-	features := make([]VideoMapFeature, 0, len(vm.Lines))
-	for _, strip := range vm.Lines {
-		if len(strip) < 2 {
-			continue // skip degenerate strips
-		}
-
-		// Geographic clipping: skip entire line strip if ANY point is outside radius
-		if doClip {
-			outside := false
-			for _, p := range strip {
-				lat, lon := float64(p[1]), float64(p[0])
-				if distanceNM(clipLat, clipLon, lat, lon) > clipRadius {
-					outside = true
-					break
-				}
+// sourceRun is a run of valid points from one source strip. It remembers
+// the source strip index so feature IDs stay stable no matter which strips
+// clipping removes.
+type sourceRun struct {
+	points       []Point2LL
+	index        int // index into vm.Lines
+	part, nParts int // run number within a split source strip
+}
+
+// featureId returns "{mapId}-{index}", plus "-{part}" for split strips
+func (r sourceRun) featureId(mapId string) string {
+	if r.nParts > 1 {
+		return fmt.Sprintf("%s-%d-%d", mapId, r.index, r.part)
+	}
+	return fmt.Sprintf("%s-%d", mapId, r.index)
+}
+
+// clipRegion is a circular clipping area
+type clipRegion struct {
+	Lat, Lon float64
+	RadiusNM float64
+}
+
+func (r clipRegion) contains(p Point2LL) bool {
+	return distanceNM(r.Lat, r.Lon, float64(p[1]), float64(p[0])) <= r.RadiusNM
+}
+
+// convertOptions controls how convertMap filters and transforms geometry
+type convertOptions struct {
+	// ClipRegions drops strips that are not fully inside at least one
+	// region, or under ClipMode "intersect" that have no point inside any
+	// region. Strips are kept or dropped whole, never cut at the boundary.
+	ClipRegions []clipRegion
+	ClipMode    string // clipModeInside ("" too) or clipModeIntersect
+	Precision   int    // coordinate decimal places
+	Winding     string // "cw"/"ccw" to normalize closed strips, "" = as-is
+	Bearings    bool   // emit per-segment bearings (0.1° resolution)
+	CumLength   bool   // emit per-point cumulative length (0.001 nm resolution)
+	FeatureIds  bool   // emit per-feature IDs from the pre-clip strip index
+	Centroid    bool   // emit each map's length-weighted centroid
+	// AdaptiveInnerNM > 0 rounds points farther than this from every clip
+	// center to AdaptiveOuterPrecision decimals (at most Precision)
+	AdaptiveInnerNM        float64
+	AdaptiveOuterPrecision int
+	// CoordinateEpsilon is the samePoint tolerance in nm for deciding a
+	// strip is closed (Winding, DetectArcs)
+	CoordinateEpsilon float64
+	// BoundingCircle emits each map's minimal enclosing circle
+	BoundingCircle bool
+	// LabelsAsFeatures adds a "label" feature with the map's Label at the
+	// centroid of its lines
+	LabelsAsFeatures bool
+	// FeatureRestrictions attaches the map's restriction (if it has one) to
+	// each feature
+	FeatureRestrictions bool
+
+	// DetectArcs emits strips that fit a circle within ArcTolerance nm as
+	// arc features instead of polylines
+	DetectArcs   bool
+	ArcTolerance float64
+	// GeodesicSpacing densifies segments along the great circle so no gap
+	// exceeds this many nm (0 = off); runs after simplification/thinning
+	GeodesicSpacing float64
+	// CollapseCollinear drops interior points within CollinearTolerance nm
+	// of the line between their neighbors; runs before simplification
+	CollapseCollinear  bool
+	CollinearTolerance float64
+	// SimplifyTolerance is the Douglas-Peucker tolerance in nm (0 = off)
+	SimplifyTolerance float64
+	// CategoryTolerances overrides SimplifyTolerance for maps of the listed
+	// Categories. -total-point-budget and -target-size-kb only adjust the
+	// global tolerance, so listed categories keep theirs.
+	CategoryTolerances map[int]float64
+	// ReverseStripOrder emits strips last to first, for sources that store
+	// them backwards. Points within a strip and feature IDs are unaffected.
+	ReverseStripOrder bool
+	// MinStripPoints drops source strips (after NaN splitting) with fewer
+	// points; values below 2 mean 2, since a line needs two points
+	MinStripPoints int
+	// MinPointSpacing thins strips so consecutive kept points are at least
+	// this many nm apart (endpoints always kept). 0 = keep every point.
+	MinPointSpacing float64
+
+	NormalizeLongitude bool // map [0,360) longitudes into [-180,180)
+	NormalizeNames     bool // trim names and collapse internal whitespace runs
+
+	SlugStrip string // separator characters for slugify (-slug-strip)
+	// ShortNameLength truncates generated (not well-known) ShortNames
+	// (0 = defaultShortNameLength)
+	ShortNameLength int
+	Renames         map[string]string // Vice name -> display name (ID unaffected)
+	IdRemap         map[string]string // generated ID -> final ID
+	NoClip          map[string]bool   // Vice names exempt from clipping
+
+	// ZOrders sets each map's ZOrder by Vice name; maps not listed use
+	// their Group. nil = no ZOrder.
+	ZOrders map[string]int
+
+	// GroupNames and CategoryNames label the numeric Group and Category.
+	// Vice stores only the numbers, so the names come from mapping files.
+	GroupNames    map[int]string
+	CategoryNames map[int]string
+}
+
+// clips reports whether clipping applies to the named map
+func (o convertOptions) clips(name string) bool {
+	return len(o.ClipRegions) > 0 && !o.NoClip[name]
+}
+
+// pointPrecision returns the decimal places to round p to
+func (o convertOptions) pointPrecision(p Point2LL) int {
+	if o.AdaptiveInnerNM <= 0 {
+		return o.Precision
+	}
+	for _, r := range o.ClipRegions {
+		if distanceNM(r.Lat, r.Lon, float64(p[1]), float64(p[0])) <= o.AdaptiveInnerNM {
+			return o.Precision
+		}
+	}
+	return min(o.AdaptiveOuterPrecision, o.Precision)
+}
+
+// Clip modes for -clip-mode
+const (
+	clipModeInside    = "inside"    // keep strips wholly inside one region
+	clipModeIntersect = "intersect" // keep strips touching any region
+)
+
+// keepsStrip reports whether clipping keeps the strip under ClipMode
+func (o convertOptions) keepsStrip(strip []Point2LL) bool {
+	if o.ClipMode == clipModeIntersect {
+		return o.touchesAnyRegion(strip)
+	}
+	return o.insideAnyRegion(strip)
+}
+
+// touchesAnyRegion reports whether at least one point of the strip lies
+// within any clip region
+func (o convertOptions) touchesAnyRegion(strip []Point2LL) bool {
+	for _, p := range strip {
+		for _, r := range o.ClipRegions {
+			if r.contains(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insideAnyRegion reports whether every point of the strip lies within a
+// single one of the clip regions (the union of regions, strip-wise).
+func (o convertOptions) insideAnyRegion(strip []Point2LL) bool {
+	for _, r := range o.ClipRegions {
+		inside := true
+		for _, p := range strip {
+			if !r.contains(p) {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+// convertMapSafe is convertMap for untrusted input: a panic while
+// converting, or a result with coordinates out of range, becomes an error
+// so the caller can skip the map and carry on. Features left with fewer
+// than MinStripPoints points are dropped (stats.Degenerate).
+func convertMapSafe(vm VideoMap, defaultVisible bool, opts convertOptions) (out OutputVideoMap, stats convertStats, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			out, stats, err = OutputVideoMap{}, convertStats{}, fmt.Errorf("conversion panicked: %v", r)
+		}
+	}()
+	out, stats = convertMap(vm, defaultVisible, opts)
+	for i, f := range out.Features {
+		for _, p := range f.Points {
+			// Longitudes may still be in [0, 360) without -normalize-longitude
+			if math.Abs(p.Lat) > 90 || math.Abs(p.Lon) > 360 {
+				return OutputVideoMap{}, convertStats{}, fmt.Errorf("feature %d: point (%g, %g) is out of range", i, p.Lat, p.Lon)
 			}
-			if outside {
+		}
+	}
+	out, stats.Degenerate = stripDegenerateFeatures(out, max(opts.MinStripPoints, 2))
+	return out, stats, nil
+}
+
+// convertMap converts one Vice map. Features come out in vm.Lines order
+// (reversed under ReverseStripOrder), a strip split at NaN/Inf points
+// yielding its parts in order; dropping strips never reorders the rest.
+// Renderers may rely on this for draw order.
+func convertMap(vm VideoMap, defaultVisible bool, opts convertOptions) (OutputVideoMap, convertStats) {
+	var stats convertStats
+	name := vm.Name
+	if opts.NormalizeNames {
+		name = normalizeName(name)
+	}
+	// The ID always derives from the Vice name so renames don't break
+	// references; an explicit remap is the only way to override it
+	id := slugify(name, opts.SlugStrip)
+	if remapped, ok := opts.IdRemap[id]; ok {
+		id = remapped
+	}
+	if display, ok := opts.Renames[vm.Name]; ok {
+		name = display
+	}
+	shortName := generateShortName(name, opts.ShortNameLength)
+
+	tolerance := opts.SimplifyTolerance
+	if t, ok := opts.CategoryTolerances[vm.Category]; ok {
+		tolerance = t
+	}
+
+	var restriction *OutputRestriction
+	if r := vm.Restriction; opts.FeatureRestrictions && (r.Id != 0 || r.Text != [2]string{}) {
+		restriction = &OutputRestriction{Id: r.Id, Text: r.Text, TextBlink: r.TextBlink, HideText: r.HideText}
+	}
+
+	// NaN/Inf is never valid geometry (typically a corrupt float32), and
+	// encoding/json would emit it as null. Drop such points and split the
+	// strip there so the surrounding segments are kept.
+	var runs []sourceRun
+	for k := range vm.Lines {
+		i := k
+		if opts.ReverseStripOrder {
+			i = len(vm.Lines) - 1 - k
+		}
+		strip := vm.Lines[i]
+		if opts.NormalizeLongitude {
+			strip = normalizeLongitudes(strip)
+		}
+		parts, invalid := splitInvalidPoints(strip)
+		stats.InvalidPoints += invalid
+		for j, part := range parts {
+			runs = append(runs, sourceRun{points: part, index: i, part: j, nParts: len(parts)})
+		}
+	}
+
+	minStrip := max(opts.MinStripPoints, 2)
+	features := make([]VideoMapFeature, 0, len(runs))
+	for _, run := range runs {
+		strip := run.points
+		if len(strip) < minStrip {
+			stats.ShortStrips++ // degenerate, or too short for -min-strip-points
+			continue
+		}
+
+		// Geographic clipping: strips are kept or skipped whole, never cut.
+		// "inside" keeps a strip one region holds all of; "intersect" keeps
+		// a strip with any point in any region.
+		if opts.clips(vm.Name) && !opts.keepsStrip(strip) {
+			stats.ClippedStrips++
+			stats.ClippedPoints += len(strip)
+			continue
+		}
+
+		if opts.DetectArcs {
+			if arc, ok := fitArc(strip, opts.ArcTolerance, opts.CoordinateEpsilon); ok {
+				arc.Center.Lat = roundCoord(arc.Center.Lat, opts.Precision)
+				arc.Center.Lon = roundCoord(arc.Center.Lon, opts.Precision)
+				arc.RadiusNM = roundCoord(arc.RadiusNM, 3)
+				arc.StartBearing = roundCoord(arc.StartBearing, 1)
+				arc.EndBearing = roundCoord(arc.EndBearing, 1)
+				feature := VideoMapFeature{Type: "arc", Arc: &arc, Restriction: restriction}
+				if opts.FeatureIds {
+					feature.FeatureId = run.featureId(id)
+				}
+				features = append(features, feature)
+				stats.Arcs++
 				continue
 			}
 		}
 
+		if opts.CollapseCollinear {
+			before := len(strip)
+			strip = collapseCollinear(strip, opts.CollinearTolerance)
+			stats.CollinearRemoved += before - len(strip)
+		}
+		if tolerance > 0 {
+			strip = simplifyRDP(strip, tolerance)
+		}
+		if opts.MinPointSpacing > 0 {
+			strip = thinBySpacing(strip, opts.MinPointSpacing)
+		}
+		if opts.GeodesicSpacing > 0 {
+			strip = densifyGreatCircle(strip, opts.GeodesicSpacing)
+		}
+
+		if opts.Winding != "" && isClosed(strip, opts.CoordinateEpsilon) {
+			ccw := signedAreaNM2(strip) > 0
+			if ccw != (opts.Winding == "ccw") {
+				strip = reversed(strip)
+			}
+		}
+
 		points := make([]Position, len(strip))
 		for j, p := range strip {
+			decimals := opts.pointPrecision(p)
 			points[j] = Position{
-				Lat: roundCoord(float64(p[1]), precision), // Point2LL[1] = latitude
-				Lon: roundCoord(float64(p[0]), precision), // Point2LL[0] = longitude
+				Lat: roundCoord(float64(p[1]), decimals), // Point2LL[1] = latitude
+				Lon: roundCoord(float64(p[0]), decimals), // Point2LL[0] = longitude
 			}
 		}
-		features = append(features, VideoMapFeature{
-			Type:   "line",
-			Points: points,
-		})
+		feature := VideoMapFeature{
+			Type:        "line",
+			Points:      points,
+			Restriction: restriction,
+		}
+		if opts.FeatureIds {
+			feature.FeatureId = run.featureId(id)
+		}
+		if opts.Bearings {
+			feature.Bearings = make([]float64, len(points)-1)
+			for j := 1; j < len(points); j++ {
+				feature.Bearings[j-1] = roundCoord(bearingDeg(points[j-1], points[j]), 1)
+			}
+		}
+		if opts.CumLength {
+			// Accumulate unrounded so rounding error doesn't build up
+			feature.CumLength = make([]float64, len(points))
+			total := 0.0
+			for j := 1; j < len(points); j++ {
+				total += distanceNM(points[j-1].Lat, points[j-1].Lon, points[j].Lat, points[j].Lon)
+				feature.CumLength[j] = roundCoord(total, 3)
+			}
+		}
+		features = append(features, feature)
+	}
+
+	var centroid *Position
+	if opts.Centroid || (opts.LabelsAsFeatures && strings.TrimSpace(vm.Label) != "") {
+		if c, ok := lineCentroid(features); ok {
+			centroid = &Position{Lat: roundCoord(c.Lat, opts.Precision), Lon: roundCoord(c.Lon, opts.Precision)}
+		}
+	}
+	// Vice labels are per map, with no coordinate (VideoMap.Lines carries
+	// no per-line labels), so the label sits at the centroid
+	if opts.LabelsAsFeatures && centroid != nil && strings.TrimSpace(vm.Label) != "" {
+		features = append(features, VideoMapFeature{Type: "label", Text: strings.TrimSpace(vm.Label), Position: centroid})
+	}
+	if !opts.Centroid {
+		centroid = nil
+	}
+	var zOrder *int
+	if opts.ZOrders != nil {
+		z, ok := opts.ZOrders[vm.Name]
+		if !ok {
+			z = vm.Group
+		}
+		zOrder = &z
+	}
+	var circle *BoundingCircle
+	if opts.BoundingCircle {
+		if c, ok := boundingCircle(features); ok {
+			c.CenterLat = roundCoord(c.CenterLat, opts.Precision)
+			c.CenterLon = roundCoord(c.CenterLon, opts.Precision)
+			c.RadiusNM = math.Ceil(c.RadiusNM*1000) / 1000 // round up: still encloses every point
+			circle = &c
+		}
 	}
 
 	return OutputVideoMap{
 		ID:             id,
-		Name:           vm.Name,
+		Name:           name,
 		ShortName:      shortName,
 		DefaultVisible: defaultVisible,
 		ViceId:         vm.Id,
 		Group:          vm.Group,
 		Category:       vm.Category,
+		GroupName:      opts.GroupNames[vm.Group],
+		CategoryName:   opts.CategoryNames[vm.Category],
 		Color:          vm.Color,
 		Features:       features,
+		Centroid:       centroid,
+		BoundingCircle: circle,
+		ZOrder:         zOrder,
+	}, stats
+}
+
+// normalizeName trims a name and collapses each internal run of
+// whitespace to a single space: "JRV  North " -> "JRV North"
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// defaultSlugStrip is the default -slug-strip set: punctuation that slugify
+// treats as a word separator, in addition to whitespace and '/'
+const defaultSlugStrip = `()[]{}.,;:!?'"#*+=|\`
+
+// slugify derives a lowercase, dash-separated ID from a map or position
+// name. Whitespace, '/', and any character in strip separate words; '&'
+// becomes "and"; runs of separators collapse to a single '-' and leading or
+// trailing separators are dropped. "PCT A&B (North).v2" -> "pct-a-and-b-north-v2"
+func slugify(name, strip string) string {
+	var b strings.Builder
+	pendingSep := false
+	word := func(w string) {
+		if pendingSep && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingSep = false
+		b.WriteString(w)
+	}
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == '&':
+			pendingSep = true
+			word("and")
+			pendingSep = true
+		case r == '-' || r == '/' || unicode.IsSpace(r) || strings.ContainsRune(strip, r):
+			pendingSep = true
+		default:
+			word(string(r))
+		}
+	}
+	return b.String()
+}
+
+// splitInvalidPoints removes NaN/Inf points from a strip, splitting it into
+// the runs of valid points between them. Returns the runs and the number of
+// points dropped. A strip with no invalid points is returned unchanged.
+func splitInvalidPoints(strip []Point2LL) ([][]Point2LL, int) {
+	var runs [][]Point2LL
+	invalid := 0
+	start := 0
+	for i, p := range strip {
+		if isFinite(p[0]) && isFinite(p[1]) {
+			continue
+		}
+		invalid++
+		if i > start {
+			runs = append(runs, strip[start:i])
+		}
+		start = i + 1
+	}
+	if invalid == 0 {
+		return [][]Point2LL{strip}, 0
+	}
+	if start < len(strip) {
+		runs = append(runs, strip[start:])
+	}
+	return runs, invalid
+}
+
+// thinBySpacing walks a strip keeping the first point and each point at
+// least spacingNM from the last kept one. The last point is always kept; if
+// it lands too close to the previous kept point, that point gives way to it.
+func thinBySpacing(strip []Point2LL, spacingNM float64) []Point2LL {
+	if len(strip) <= 2 {
+		return strip
+	}
+	dist := func(a, b Point2LL) float64 {
+		return distanceNM(float64(a[1]), float64(a[0]), float64(b[1]), float64(b[0]))
+	}
+
+	out := []Point2LL{strip[0]}
+	for _, p := range strip[1 : len(strip)-1] {
+		if dist(out[len(out)-1], p) >= spacingNM {
+			out = append(out, p)
+		}
+	}
+	last := strip[len(strip)-1]
+	if len(out) > 1 && dist(out[len(out)-1], last) < spacingNM {
+		out[len(out)-1] = last
+	} else {
+		out = append(out, last)
+	}
+	return out
+}
+
+// normalizeLongitudes returns the strip with any longitude > 180 shifted by
+// -360. The source is copied only if a point actually changes.
+func normalizeLongitudes(strip []Point2LL) []Point2LL {
+	var out []Point2LL
+	for i, p := range strip {
+		if p[0] <= 180 {
+			continue
+		}
+		if out == nil {
+			out = slices.Clone(strip)
+		}
+		out[i][0] = p[0] - 360
+	}
+	if out == nil {
+		return strip
+	}
+	return out
+}
+
+// isClosed reports whether a strip is a ring: its last point is within
+// epsNM of its first (-coordinate-epsilon; 0 = identical)
+func isClosed(strip []Point2LL, epsNM float64) bool {
+	if len(strip) < 4 {
+		return false
+	}
+	first, last := strip[0], strip[len(strip)-1]
+	return samePoint(float64(first[1]), float64(first[0]), float64(last[1]), float64(last[0]), epsNM)
+}
+
+// samePoint reports whether two points are within epsNM of each other.
+// This is the one "equal within tolerance" test (-coordinate-epsilon): it
+// decides whether a strip is closed for -winding, -detect-arcs (closed
+// strips are never arcs), and -detect-polygons. Hash-based comparisons
+// (-dedupe-maps, -report-duplicate-strips, -since) stay exact on the
+// rounded output.
+func samePoint(lat1, lon1, lat2, lon2, epsNM float64) bool {
+	return (lat1 == lat2 && lon1 == lon2) || distanceNM(lat1, lon1, lat2, lon2) <= epsNM
+}
+
+// signedAreaNM2 returns the shoelace area of a ring in square nm using a flat
+// projection about the ring's first point. Positive = counter-clockwise.
+func signedAreaNM2(ring []Point2LL) float64 {
+	lon0, lat0 := float64(ring[0][0]), float64(ring[0][1])
+	kx, ky := nmPerDegLon(lat0), nmPerDegLat(lat0)
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		x1 := (float64(ring[i][0]) - lon0) * kx
+		y1 := (float64(ring[i][1]) - lat0) * ky
+		x2 := (float64(ring[i+1][0]) - lon0) * kx
+		y2 := (float64(ring[i+1][1]) - lat0) * ky
+		area += x1*y2 - x2*y1
+	}
+	return area / 2
+}
+
+// lineCentroid returns the length-weighted centroid of the features' points:
+// the mean of segment midpoints weighted by segment length, in a flat
+// projection about the first point. Video maps are line work (closed rings
+// are outlines, not filled areas), so length weighting is used throughout;
+// arc features are skipped. Features whose segments all have zero length
+// fall back to the plain mean of their points. Reports false if there are
+// no points.
+func lineCentroid(features []VideoMapFeature) (Position, bool) {
+	var origin Position
+	found := false
+	for _, f := range features {
+		if len(f.Points) > 0 {
+			origin, found = f.Points[0], true
+			break
+		}
+	}
+	if !found {
+		return Position{}, false
+	}
+
+	kx, ky := nmPerDegLon(origin.Lat), nmPerDegLat(origin.Lat)
+	xy := func(p Position) (float64, float64) {
+		return (p.Lon - origin.Lon) * kx, (p.Lat - origin.Lat) * ky
+	}
+	var sx, sy, total float64
+	var mx, my float64
+	n := 0
+	for _, f := range features {
+		for i, p := range f.Points {
+			x, y := xy(p)
+			mx, my = mx+x, my+y
+			n++
+			if i == 0 {
+				continue
+			}
+			x0, y0 := xy(f.Points[i-1])
+			length := math.Hypot(x-x0, y-y0)
+			sx += (x + x0) / 2 * length
+			sy += (y + y0) / 2 * length
+			total += length
+		}
+	}
+	cx, cy := mx/float64(n), my/float64(n)
+	if total > 0 {
+		cx, cy = sx/total, sy/total
+	}
+	return Position{Lat: origin.Lat + cy/ky, Lon: origin.Lon + cx/kx}, true
+}
+
+// reversed returns a reversed copy of a strip (the source is not modified)
+func reversed(strip []Point2LL) []Point2LL {
+	out := make([]Point2LL, len(strip))
+	for i, p := range strip {
+		out[len(strip)-1-i] = p
+	}
+	return out
+}
+
+func isFinite(v float32) bool {
+	f := float64(v)
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// disambiguateShortNames makes ShortNames unique by appending a digit to
+// every repeat after the first (replacing the last characters if the name
+// would exceed maxLen, the -short-name-length; 0 = defaultShortNameLength).
+// Returns a description of each collision.
+func disambiguateShortNames(maps []OutputVideoMap, maxLen int) []string {
+	if maxLen <= 0 {
+		maxLen = defaultShortNameLength
+	}
+	used := make(map[string]string, len(maps)) // ShortName -> map name that owns it
+	for _, m := range maps {
+		if _, ok := used[m.ShortName]; !ok {
+			used[m.ShortName] = m.Name
+		}
+	}
+
+	var collisions []string
+	seen := make(map[string]bool, len(maps))
+	for i := range maps {
+		short := maps[i].ShortName
+		if !seen[short] {
+			seen[short] = true
+			continue
+		}
+		renamed := short
+		for n := 2; ; n++ {
+			suffix := strconv.Itoa(n)
+			base := short
+			if len(base)+len(suffix) > maxLen {
+				base = base[:max(maxLen-len(suffix), 0)]
+			}
+			renamed = base + suffix
+			if _, taken := used[renamed]; !taken {
+				break
+			}
+		}
+		used[renamed] = maps[i].Name
+		seen[renamed] = true
+		maps[i].ShortName = renamed
+		collisions = append(collisions, fmt.Sprintf("'%s' and '%s' both generate %q; renamed the latter to %q",
+			used[short], maps[i].Name, short, renamed))
 	}
+	return collisions
 }
 
-// generateShortName produces a short label (max 8 chars) for DCB buttons
-func generateShortName(name string) string {
+// Generated ShortName lengths (-short-name-length); no DCB button fits
+// more than maxShortNameLength
+const (
+	defaultShortNameLength = 8
+	maxShortNameLength     = 16
+)
+
+// generateShortName produces a short label for DCB buttons. Known maps use
+// their fixed names; others are truncated to maxLen characters (0 means
+// defaultShortNameLength).
+func generateShortName(name string, maxLen int) string {
+	if maxLen <= 0 {
+		maxLen = defaultShortNameLength
+	}
 	// Well-known PCT/JRV map short names
 	known := map[string]string{
 		"PCT Coastlines":     "COAST",
@@ -428,8 +3310,8 @@ func generateShortName(name string) string {
 	for _, prefix := range []string{"PCT ", "JRV ", "RIC "} {
 		s = strings.TrimPrefix(s, prefix)
 	}
-	if len(s) > 8 {
-		s = s[:8]
+	if len(s) > maxLen {
+		s = s[:maxLen]
 	}
 	return strings.TrimSpace(s)
 }
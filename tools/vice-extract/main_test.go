@@ -0,0 +1,960 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestConvertMapDropsNaNPoints(t *testing.T) {
+	nan := float32(math.NaN())
+	vm := VideoMap{
+		Name: "Test",
+		Lines: [][]Point2LL{{
+			{-77.0, 37.0},
+			{-77.1, 37.1},
+			{nan, 37.2}, // corrupt point splits the strip here
+			{-77.3, 37.3},
+			{-77.4, 37.4},
+		}},
+	}
+
+	out, stats := convertMap(vm, false, convertOptions{Precision: 5})
+
+	if stats.InvalidPoints != 1 {
+		t.Errorf("InvalidPoints = %d, want 1", stats.InvalidPoints)
+	}
+	if len(out.Features) != 2 {
+		t.Fatalf("got %d features, want 2 (strip split at NaN)", len(out.Features))
+	}
+	for i, f := range out.Features {
+		if len(f.Points) != 2 {
+			t.Errorf("feature %d has %d points, want 2", i, len(f.Points))
+		}
+		for _, p := range f.Points {
+			if math.IsNaN(p.Lat) || math.IsNaN(p.Lon) {
+				t.Errorf("feature %d contains NaN point %+v", i, p)
+			}
+		}
+	}
+}
+
+func TestConvertMapWindingClockwiseToCCW(t *testing.T) {
+	// Clockwise square (N-up, E-right): SW -> NW -> NE -> SE -> SW
+	ring := []Point2LL{
+		{-77.0, 37.0},
+		{-77.0, 37.1},
+		{-76.9, 37.1},
+		{-76.9, 37.0},
+		{-77.0, 37.0},
+	}
+	if signedAreaNM2(ring) >= 0 {
+		t.Fatalf("test ring should be clockwise, got area %f", signedAreaNM2(ring))
+	}
+	vm := VideoMap{Name: "Ring", Lines: [][]Point2LL{ring}}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, Winding: "ccw"})
+
+	pts := out.Features[0].Points
+	got := make([]Point2LL, len(pts))
+	for i, p := range pts {
+		got[i] = Point2LL{float32(p.Lon), float32(p.Lat)}
+	}
+	if signedAreaNM2(got) <= 0 {
+		t.Errorf("output ring not counter-clockwise: %+v", pts)
+	}
+	if pts[1].Lon != -76.9 || pts[1].Lat != 37.0 {
+		t.Errorf("second point = %+v, want reversed order starting SW -> SE", pts[1])
+	}
+	if ring[1] != (Point2LL{-77.0, 37.1}) {
+		t.Errorf("source ring was modified: %+v", ring)
+	}
+}
+
+func TestConvertMapClipUnionOfRegions(t *testing.T) {
+	vm := VideoMap{
+		Name: "Two Areas",
+		Lines: [][]Point2LL{
+			{{-77.30, 37.50}, {-77.25, 37.52}}, // inside region A
+			{{-76.00, 39.00}, {-76.05, 39.02}}, // inside region B
+			{{-77.30, 37.50}, {-76.00, 39.00}}, // spans both, fully inside neither
+			{{-80.00, 35.00}, {-80.05, 35.02}}, // outside both
+		},
+	}
+	opts := convertOptions{
+		Precision: 5,
+		ClipRegions: []clipRegion{
+			{Lat: 37.5, Lon: -77.3, RadiusNM: 20},
+			{Lat: 39.0, Lon: -76.0, RadiusNM: 20},
+		},
+	}
+
+	out, _ := convertMap(vm, false, opts)
+
+	if len(out.Features) != 2 {
+		t.Fatalf("got %d features, want 2: %+v", len(out.Features), out.Features)
+	}
+	if out.Features[0].Points[0].Lat != 37.5 || out.Features[1].Points[0].Lat != 39.0 {
+		t.Errorf("kept wrong strips: %+v", out.Features)
+	}
+}
+
+func TestConvertMapClipModeIntersect(t *testing.T) {
+	vm := VideoMap{
+		Name: "Straddle",
+		Lines: [][]Point2LL{
+			{{-77.30, 37.50}, {-77.25, 37.52}}, // inside
+			{{-77.30, 37.50}, {-76.00, 37.50}}, // straddles the boundary
+			{{-80.00, 35.00}, {-80.05, 35.02}}, // outside
+		},
+	}
+	opts := convertOptions{Precision: 5, ClipRegions: []clipRegion{{Lat: 37.5, Lon: -77.3, RadiusNM: 20}}}
+
+	if out, _ := convertMap(vm, false, opts); len(out.Features) != 1 {
+		t.Errorf("inside mode: got %d features, want 1", len(out.Features))
+	}
+	opts.ClipMode = clipModeIntersect
+	out, stats := convertMap(vm, false, opts)
+	if len(out.Features) != 2 || stats.ClippedStrips != 1 {
+		t.Fatalf("intersect mode: got %d features (%d clipped), want 2 (1 clipped)", len(out.Features), stats.ClippedStrips)
+	}
+	if straddle := out.Features[1].Points; len(straddle) != 2 || straddle[1].Lon != -76 {
+		t.Errorf("straddling strip should be kept whole, got %+v", straddle)
+	}
+}
+
+func TestConvertMapCumLength(t *testing.T) {
+	vm := VideoMap{
+		Name: "Route",
+		Lines: [][]Point2LL{{
+			{-77.0, 37.0},
+			{-77.0, 37.5}, // 30 nm north
+			{-76.5, 37.5},
+			{-76.5, 38.0}, // 30 nm north
+		}},
+	}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, CumLength: true})
+
+	f := out.Features[0]
+	got := f.CumLength
+	if len(got) != len(f.Points) {
+		t.Fatalf("got %d lengths, want %d (one per point)", len(got), len(f.Points))
+	}
+	if got[0] != 0 {
+		t.Errorf("first length = %v, want 0", got[0])
+	}
+	for j := 1; j < len(got); j++ {
+		if got[j] <= got[j-1] {
+			t.Errorf("lengths not increasing at %d: %v", j, got)
+		}
+	}
+	total := 0.0
+	for j := 1; j < len(f.Points); j++ {
+		total += distanceNM(f.Points[j-1].Lat, f.Points[j-1].Lon, f.Points[j].Lat, f.Points[j].Lon)
+	}
+	if math.Abs(got[len(got)-1]-total) > 0.001 {
+		t.Errorf("last length = %v, want total %v", got[len(got)-1], total)
+	}
+	if math.Abs(got[1]-30) > 0.1 {
+		t.Errorf("length after 0.5° north = %v, want ~30 nm", got[1])
+	}
+}
+
+func TestConvertMapBearings(t *testing.T) {
+	vm := VideoMap{
+		Name: "Airway",
+		Lines: [][]Point2LL{{
+			{-77.0, 37.0},
+			{-77.0, 37.5}, // due north
+			{-76.5, 37.5}, // due east
+		}},
+	}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, Bearings: true})
+
+	got := out.Features[0].Bearings
+	if len(got) != 2 {
+		t.Fatalf("got %d bearings, want 2 (one per segment)", len(got))
+	}
+	if got[0] != 0 {
+		t.Errorf("north segment bearing = %v, want 0", got[0])
+	}
+	if got[1] != 90 {
+		t.Errorf("east segment bearing = %v, want 90", got[1])
+	}
+}
+
+func TestConvertMapNormalizeLongitude(t *testing.T) {
+	vm := VideoMap{
+		Name:  "East Wrapped",
+		Lines: [][]Point2LL{{{283.0, 37.5}, {-77.1, 37.6}}},
+	}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, NormalizeLongitude: true})
+
+	pts := out.Features[0].Points
+	if pts[0].Lon != -77 {
+		t.Errorf("lon = %v, want -77", pts[0].Lon)
+	}
+	if pts[1].Lon != -77.1 {
+		t.Errorf("in-range lon changed to %v, want -77.1", pts[1].Lon)
+	}
+	if vm.Lines[0][0][0] != 283 {
+		t.Errorf("source point was modified: %v", vm.Lines[0][0])
+	}
+}
+
+func TestThinBySpacingEvenLine(t *testing.T) {
+	// 101 points due north, 0.001° (0.06 nm) apart: 6 nm total
+	strip := make([]Point2LL, 101)
+	for i := range strip {
+		strip[i] = Point2LL{-77.0, 37.0 + float32(i)*0.001}
+	}
+
+	got := thinBySpacing(strip, 0.5)
+
+	if got[0] != strip[0] || got[len(got)-1] != strip[len(strip)-1] {
+		t.Errorf("endpoints not preserved: first %v last %v", got[0], got[len(got)-1])
+	}
+	// Every 9th point (0.54 nm) survives the greedy walk: 0, 9, ..., 99, then 100
+	// replaces 99 as the endpoint
+	if len(got) != 12 {
+		t.Errorf("kept %d points, want 12", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		d := distanceNM(float64(got[i-1][1]), float64(got[i-1][0]), float64(got[i][1]), float64(got[i][0]))
+		if d < 0.5 {
+			t.Errorf("points %d-%d are %.3f nm apart, want >= 0.5", i-1, i, d)
+		}
+	}
+}
+
+func TestConvertMapFeatureIdsUsePreClipIndex(t *testing.T) {
+	vm := VideoMap{
+		Name: "JRV North",
+		Lines: [][]Point2LL{
+			{{-80.00, 35.00}, {-80.05, 35.02}}, // clipped away
+			{{-77.30, 37.50}, {-77.25, 37.52}},
+		},
+	}
+	opts := convertOptions{
+		Precision:   5,
+		FeatureIds:  true,
+		ClipRegions: []clipRegion{{Lat: 37.5, Lon: -77.3, RadiusNM: 20}},
+	}
+
+	out, _ := convertMap(vm, false, opts)
+
+	if len(out.Features) != 1 {
+		t.Fatalf("got %d features, want 1", len(out.Features))
+	}
+	if got := out.Features[0].FeatureId; got != "jrv-north-1" {
+		t.Errorf("FeatureId = %q, want %q", got, "jrv-north-1")
+	}
+}
+
+func TestGenerateShortNameLength(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		maxLen int
+		want   string
+	}{
+		{"PCT Approach East", 6, "Approa"},
+		{"PCT Approach East", 10, "Approach E"},
+		{"PCT Approach East", 0, "Approach"}, // default 8
+		{"PCT Zone", 10, "Zone"},             // fits: unchanged
+		{"PCT Coastlines", 3, "COAST"},       // well-known: verbatim
+		{"PCT MEGA Combined", 10, "MEGA"},
+	} {
+		if got := generateShortName(tc.name, tc.maxLen); got != tc.want {
+			t.Errorf("generateShortName(%q, %d) = %q, want %q", tc.name, tc.maxLen, got, tc.want)
+		}
+	}
+}
+
+func TestDisambiguateShortNames(t *testing.T) {
+	maps := []OutputVideoMap{
+		{Name: "PCT Approach East", ShortName: "Approach"},
+		{Name: "PCT Approach West", ShortName: "Approach"},
+		{Name: "JRV North", ShortName: "NORTH"},
+		{Name: "RIC North", ShortName: "NORTH"},
+	}
+
+	collisions := disambiguateShortNames(maps, 0)
+
+	if len(collisions) != 2 {
+		t.Errorf("got %d collisions, want 2: %v", len(collisions), collisions)
+	}
+	want := []string{"Approach", "Approac2", "NORTH", "NORTH2"}
+	for i, m := range maps {
+		if m.ShortName != want[i] {
+			t.Errorf("maps[%d].ShortName = %q, want %q", i, m.ShortName, want[i])
+		}
+	}
+}
+
+func TestDisambiguateShortNamesLength(t *testing.T) {
+	for _, tc := range []struct {
+		maxLen int
+		short  string
+		want   string
+	}{
+		{12, "ApproachEast", "ApproachEas2"},
+		{12, "Approach", "Approach2"}, // room for the suffix, nothing cut
+		{4, "APCH", "APC2"},
+	} {
+		maps := []OutputVideoMap{{Name: "A", ShortName: tc.short}, {Name: "B", ShortName: tc.short}}
+		disambiguateShortNames(maps, tc.maxLen)
+		if got := maps[1].ShortName; got != tc.want || len(got) > tc.maxLen {
+			t.Errorf("length %d: %q renamed to %q, want %q", tc.maxLen, tc.short, got, tc.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"PCT MVA", "pct-mva"},
+		{"RIC IAP H02-Y", "ric-iap-h02-y"},
+		{"Class B/C", "class-b-c"},
+		{"Roads & Rivers", "roads-and-rivers"},
+		{"A&B", "a-and-b"},
+		{"JRV North (Day)", "jrv-north-day"},
+		{"Sat Approaches v1.2", "sat-approaches-v1-2"},
+		{"  Doubled  Space  ", "doubled-space"},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.name, defaultSlugStrip); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAllMapNames(t *testing.T) {
+	maps := []VideoMap{{Name: "PCT MVA"}, {Name: "JRV North"}, {Name: "PCT MVA"}}
+	manifest := map[string]any{"JRV North": nil, "Zeta": nil, "Alpha": nil}
+
+	got := allMapNames(maps, manifest)
+	want := []string{"PCT MVA", "JRV North", "Alpha", "Zeta"}
+	if !slices.Equal(got, want) {
+		t.Errorf("allMapNames = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeVideoMapsMaxDecodeBytes(t *testing.T) {
+	var buf bytes.Buffer
+	lib := VideoMapLibrary{Maps: []VideoMap{{Name: "Big", Lines: [][]Point2LL{make([]Point2LL, 1000)}}}}
+	if err := gob.NewEncoder(&buf).Encode(lib); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := decodeVideoMaps(buf.Bytes(), loadOptions{Format: "gob", MaxDecodeBytes: 100})
+	if !errors.Is(err, errDecodeLimit) {
+		t.Errorf("limit 100: err = %v, want errDecodeLimit", err)
+	}
+	got, _, err := decodeVideoMaps(buf.Bytes(), loadOptions{Format: "gob", MaxDecodeBytes: 1 << 20})
+	if err != nil || len(got.Maps) != 1 {
+		t.Errorf("limit 1 MiB: got %v, %v; want 1 map", got, err)
+	}
+}
+
+func TestDecodeVideoMapsVerbose(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(fixtureLibrary()); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = &log
+
+	if _, _, err := decodeVideoMaps(buf.Bytes(), loadOptions{Format: "gob", Verbose: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("library decode ok: read %d bytes", buf.Len())
+	if !strings.Contains(log.String(), want) || strings.Contains(log.String(), "WARNING") {
+		t.Errorf("log = %q, want %q and no warning", log.String(), want)
+	}
+
+	log.Reset()
+	decodeVideoMaps(buf.Bytes()[:40], loadOptions{Format: "gob", Verbose: true})
+	if !strings.Contains(log.String(), "WARNING: decompressed stream is only 40 bytes") {
+		t.Errorf("truncated input: log = %q, want a short-stream warning", log.String())
+	}
+}
+
+func TestDecodeVideoMapsNoFallback(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(fixtureLibrary().Maps); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, layout, err := decodeVideoMaps(buf.Bytes(), loadOptions{Format: "gob"}); err != nil || layout != layoutLegacy {
+		t.Errorf("with fallback: layout %q, err %v; want legacy decode", layout, err)
+	}
+	_, _, err := decodeVideoMaps(buf.Bytes(), loadOptions{Format: "gob", NoFallback: true})
+	if err == nil || strings.Contains(err.Error(), "both formats") {
+		t.Errorf("-no-fallback: err = %v, want the library decode error alone", err)
+	}
+}
+
+func TestConvertMapFeatureRestrictions(t *testing.T) {
+	vm := fixtureLibrary().Maps[1] // restricted MVA map
+	vm.Lines = append(vm.Lines, []Point2LL{{-77.3, 37.5}, {-77.2, 37.5}})
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, FeatureRestrictions: true})
+	for i, f := range out.Features {
+		if f.Restriction == nil || f.Restriction.Id != 1 || f.Restriction.Text != [2]string{"MVA", "030"} {
+			t.Errorf("feature %d restriction = %+v, want map-level {1 [MVA 030]}", i, f.Restriction)
+		}
+	}
+
+	out, _ = convertMap(fixtureLibrary().Maps[0], false, convertOptions{Precision: 5, FeatureRestrictions: true})
+	if r := out.Features[0].Restriction; r != nil {
+		t.Errorf("unrestricted map: restriction = %+v, want nil", r)
+	}
+	out, _ = convertMap(vm, false, convertOptions{Precision: 5})
+	if r := out.Features[0].Restriction; r != nil {
+		t.Errorf("flag off: restriction = %+v, want nil", r)
+	}
+}
+
+func TestRoundTripCheck(t *testing.T) {
+	dir := t.TempDir()
+	maps := []OutputVideoMap{{ID: "m", Name: "M", ShortName: "M", Features: []VideoMapFeature{
+		{Type: "line", Points: []Position{{Lat: 37.5, Lon: -77.3}, {Lat: 37.51, Lon: -77.29}}},
+	}}}
+	for _, oo := range []outputOptions{{}, {Compact: true, MinimalFields: true}, {Wrap: &OutputMetadata{Generator: "test"}}} {
+		oo.RoundTripCheck = true
+		if _, err := writeMaps(filepath.Join(dir, "ok.json"), maps, oo); err != nil {
+			t.Errorf("%+v: %v", oo, err)
+		}
+	}
+
+	// A field the output types don't know is lost on re-read
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`[{"id":"m","extra":1,"name":"M","shortName":"M","defaultVisible":false,"viceId":0,"group":0,"category":0,"color":0,"features":[]}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTripCheck(bad, outputOptions{}); err == nil {
+		t.Error("roundTripCheck accepted a file that doesn't round-trip")
+	}
+}
+
+func TestMergeManifest(t *testing.T) {
+	into := map[string]any{"PCT MVA": []string{}, "JRV North": []string{"a"}}
+	from := map[string]any{"JRV North": []string{"b"}, "RIC IAP": []string{}, "PCT MVA": []string{}}
+
+	conflicts := mergeManifest(into, from)
+	if !slices.Equal(conflicts, []string{"JRV North"}) {
+		t.Errorf("conflicts = %q, want [JRV North]", conflicts)
+	}
+	if len(into) != 3 {
+		t.Errorf("merged %d names, want 3", len(into))
+	}
+	if got := into["JRV North"].([]string); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("conflicting entry = %q, want the first manifest's [a]", got)
+	}
+}
+
+func TestLoadCategoryTolerances(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tol.json")
+	if err := os.WriteFile(path, []byte(`{"0": 0.05, /* airways */ " 3 ": 0,}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadCategoryTolerances(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 0.05 || got[3] != 0 {
+		t.Errorf("got %v", got)
+	}
+
+	for _, bad := range []string{`{"coast": 0.1}`, `{"1": -0.1}`} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadCategoryTolerances(path); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestScenarioVisibility(t *testing.T) {
+	visible, mentioned := scenarioVisibility(map[string]ViceControllerConfig{
+		"1R": {VideoMaps: []string{"A", "B"}, DefaultMaps: []string{"B"}},
+		"2R": {VideoMaps: []string{"C"}, DefaultMaps: []string{"D"}},
+	})
+	for name, want := range map[string][2]bool{
+		"A": {false, true}, "B": {true, true}, "C": {false, true}, "D": {true, true}, "E": {false, false},
+	} {
+		if visible[name] != want[0] || mentioned[name] != want[1] {
+			t.Errorf("%s: visible=%v mentioned=%v, want %v", name, visible[name], mentioned[name], want)
+		}
+	}
+}
+
+func TestScenarioPosition(t *testing.T) {
+	var sg ViceScenarioGroup
+	sg.STARSConfig.ControllerConfigs = map[string]ViceControllerConfig{
+		"1R": {VideoMaps: []string{"A", "B"}},
+		"2R": {VideoMaps: []string{"C"}},
+	}
+	if pos, cc, err := scenarioPosition(&sg, "2R"); err != nil || pos != "2R" || !slices.Equal(cc.VideoMaps, []string{"C"}) {
+		t.Errorf("2R: got %s %v, err %v", pos, cc.VideoMaps, err)
+	}
+	if _, _, err := scenarioPosition(&sg, ""); err == nil || !strings.Contains(err.Error(), "1R, 2R") {
+		t.Errorf("no position with two: err = %v, want one listing the positions", err)
+	}
+	if _, _, err := scenarioPosition(&sg, "3R"); err == nil {
+		t.Error("unknown position: expected error")
+	}
+	delete(sg.STARSConfig.ControllerConfigs, "2R")
+	if pos, _, err := scenarioPosition(&sg, ""); err != nil || pos != "1R" {
+		t.Errorf("single position: got %s, err %v", pos, err)
+	}
+}
+
+func TestRunScenarioFailOnSkipWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	scenario := filepath.Join(dir, "scenario.json")
+	sg := `{"stars_config": {"controller_configs": {
+		"1R": {"video_maps": ["Good"]},
+		"2R": {"video_maps": ["Bad"]}
+	}}}`
+	if err := os.WriteFile(scenario, []byte(sg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lib := &VideoMapLibrary{Maps: []VideoMap{
+		{Name: "Good", Lines: [][]Point2LL{{{-77, 37}, {-76, 37}}}},
+		{Name: "Bad", Lines: [][]Point2LL{{{-77, 137}, {-76, 37}}}}, // latitude out of range
+	}}
+
+	out := filepath.Join(dir, "out")
+	err := runScenario(scenario, lib, convertOptions{Precision: 5}, out, outputOptions{}, true)
+	if err == nil {
+		t.Fatal("expected -fail-on-skip error")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("output directory written despite -fail-on-skip (stat err %v)", err)
+	}
+}
+
+// BenchmarkDecodeVideoMaps compares zstd decoder concurrency and
+// buffered vs streaming decompression (-decode-jobs, -decode-buffer) on a
+// ~38 MB (decompressed) library
+func BenchmarkDecodeVideoMaps(b *testing.B) {
+	var lib VideoMapLibrary
+	for m := 0; m < 10; m++ {
+		vm := VideoMap{Name: fmt.Sprintf("Map %d", m), Id: m + 1}
+		for k := 0; k < 300; k++ {
+			strip := make([]Point2LL, 1000)
+			for j := range strip {
+				strip[j] = Point2LL{-77.3 + float32(j)*1e-4, 37.5 + float32(k)*1e-4}
+			}
+			vm.Lines = append(vm.Lines, strip)
+		}
+		lib.Maps = append(lib.Maps, vm)
+	}
+	var raw bytes.Buffer
+	if err := gob.NewEncoder(&raw).Encode(lib); err != nil {
+		b.Fatal(err)
+	}
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := enc.EncodeAll(raw.Bytes(), nil)
+
+	for _, jobs := range []int{1, 0} {
+		for _, buffer := range []bool{false, true} {
+			b.Run(fmt.Sprintf("jobs=%d/buffer=%v", jobs, buffer), func(b *testing.B) {
+				lo := loadOptions{Format: "zstd", DecodeJobs: jobs, BufferDecompressed: buffer}
+				b.SetBytes(int64(raw.Len()))
+				for b.Loop() {
+					if _, _, err := decodeVideoMaps(data, lo); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeZstdMaxMemory(t *testing.T) {
+	// ~200 KB of gob: over the 64 KiB limit below, under the 1 MiB one
+	strip := make([]Point2LL, 20000)
+	for j := range strip {
+		strip[j] = Point2LL{-77 + float32(j)*1e-4, 37}
+	}
+	lib := VideoMapLibrary{Maps: []VideoMap{{Name: "A", Id: 1, Lines: [][]Point2LL{strip}}}}
+	var raw bytes.Buffer
+	if err := gob.NewEncoder(&raw).Encode(lib); err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	enc, err := zstd.NewWriter(&compressed, zstd.WithWindowSize(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.Write(raw.Bytes())
+	enc.Close()
+
+	_, _, err = decodeVideoMaps(compressed.Bytes(), loadOptions{Format: "zstd", ZstdMaxMemory: 64 << 10})
+	if err == nil || !strings.Contains(err.Error(), "-zstd-max-memory") {
+		t.Errorf("64 KiB limit on a 1 MiB window: err = %v, want a -zstd-max-memory error", err)
+	}
+	got, _, err := decodeVideoMaps(compressed.Bytes(), loadOptions{Format: "zstd", ZstdMaxMemory: 1 << 20})
+	if err != nil || len(got.Maps) != 1 {
+		t.Errorf("1 MiB limit: err = %v", err)
+	}
+	if _, _, err := decodeVideoMaps(compressed.Bytes(), loadOptions{Format: "zstd"}); err != nil {
+		t.Errorf("no limit: err = %v", err)
+	}
+}
+
+func TestLineCentroidSquare(t *testing.T) {
+	square := []Position{
+		{Lat: 37.0, Lon: -77.5}, {Lat: 37.0, Lon: -77.0}, {Lat: 37.5, Lon: -77.0},
+		{Lat: 37.5, Lon: -77.5}, {Lat: 37.0, Lon: -77.5},
+	}
+	got, ok := lineCentroid([]VideoMapFeature{{Type: "line", Points: square}})
+	if !ok || math.Abs(got.Lat-37.25) > 1e-9 || math.Abs(got.Lon+77.25) > 1e-9 {
+		t.Errorf("square centroid = %+v, %v; want {37.25 -77.25}", got, ok)
+	}
+	// Extra vertices on one side would pull a point mean; length weighting
+	// doesn't care how the outline is sampled
+	resampled := slices.Concat(square[:3], []Position{{Lat: 37.5, Lon: -77.25}}, square[3:])
+	got, _ = lineCentroid([]VideoMapFeature{{Type: "line", Points: resampled}})
+	if math.Abs(got.Lat-37.25) > 1e-9 || math.Abs(got.Lon+77.25) > 1e-9 {
+		t.Errorf("resampled square centroid = %+v, want {37.25 -77.25}", got)
+	}
+
+	if _, ok := lineCentroid([]VideoMapFeature{{Type: "arc"}}); ok {
+		t.Error("no points: want ok = false")
+	}
+}
+
+func TestConvertMapMinStripPoints(t *testing.T) {
+	vm := VideoMap{Name: "Strips", Lines: [][]Point2LL{
+		{{-77, 37}},            // degenerate
+		{{-77, 37}, {-76, 37}}, // 2 points
+		{{-77, 37}, {-76, 37}, {-76, 38}, {-77, 37}}, // closed triangle
+	}}
+	for _, tt := range []struct{ min, features, short int }{
+		{0, 2, 1}, // default: only the degenerate strip goes
+		{2, 2, 1},
+		{4, 1, 2},
+		{5, 0, 3},
+	} {
+		out, stats := convertMap(vm, false, convertOptions{Precision: 5, MinStripPoints: tt.min})
+		if len(out.Features) != tt.features || stats.ShortStrips != tt.short {
+			t.Errorf("min %d: %d features, %d short strips; want %d, %d",
+				tt.min, len(out.Features), stats.ShortStrips, tt.features, tt.short)
+		}
+	}
+}
+
+func TestConvertMapStripOrder(t *testing.T) {
+	vm := VideoMap{Name: "Order", Lines: [][]Point2LL{
+		{{-77, 37}, {-76, 37}},
+		{{-77, 38}, {-76, 38}, {float32(math.NaN()), 0}, {-75, 38}, {-74, 38}}, // splits in two
+		{{-77, 39}}, // dropped
+		{{-77, 40}, {-76, 40}},
+	}}
+	firstPoints := func(out OutputVideoMap) []string {
+		var got []string
+		for _, f := range out.Features {
+			got = append(got, fmt.Sprintf("%s@%g,%g", f.FeatureId, f.Points[0].Lat, f.Points[0].Lon))
+		}
+		return got
+	}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, FeatureIds: true})
+	want := []string{"order-0@37,-77", "order-1-0@38,-77", "order-1-1@38,-75", "order-3@40,-77"}
+	if got := firstPoints(out); !slices.Equal(got, want) {
+		t.Errorf("source order: got %q, want %q", got, want)
+	}
+
+	out, _ = convertMap(vm, false, convertOptions{Precision: 5, FeatureIds: true, ReverseStripOrder: true})
+	want = []string{"order-3@40,-77", "order-1-0@38,-77", "order-1-1@38,-75", "order-0@37,-77"}
+	if got := firstPoints(out); !slices.Equal(got, want) {
+		t.Errorf("reversed: got %q, want %q", got, want)
+	}
+}
+
+func TestConvertMapLabelsAsFeatures(t *testing.T) {
+	vm := VideoMap{Name: "Fixes", Label: " DUCXS ", Lines: [][]Point2LL{
+		{{-77, 37}, {-76, 37}, {-76, 38}, {-77, 38}, {-77, 37}},
+	}}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5})
+	if len(out.Features) != 1 {
+		t.Fatalf("labels off: %d features, want 1", len(out.Features))
+	}
+
+	out, _ = convertMap(vm, false, convertOptions{Precision: 5, LabelsAsFeatures: true})
+	if len(out.Features) != 2 || out.Centroid != nil {
+		t.Fatalf("labels on: %d features (centroid %v), want 2 and no centroid", len(out.Features), out.Centroid)
+	}
+	label := out.Features[1]
+	if label.Type != "label" || label.Text != "DUCXS" || label.Position == nil ||
+		*label.Position != (Position{Lat: 37.5, Lon: -76.5}) || label.Points != nil {
+		t.Errorf("label feature = %+v (position %v)", label, label.Position)
+	}
+
+	vm.Label = ""
+	if out, _ = convertMap(vm, false, convertOptions{Precision: 5, LabelsAsFeatures: true}); len(out.Features) != 1 {
+		t.Errorf("no Label: %d features, want 1", len(out.Features))
+	}
+}
+
+// TestOutputDeterministic guards content-addressed pipelines: the same
+// input and options must always produce byte-identical output
+func TestOutputDeterministic(t *testing.T) {
+	opts := convertOptions{
+		Precision: 5, FeatureIds: true, Bearings: true, Centroid: true, LabelsAsFeatures: true,
+		SimplifyTolerance: 0.01, CategoryTolerances: map[int]float64{0: 0.02, 3: 0},
+	}
+	render := func() []byte {
+		var maps []OutputVideoMap
+		for _, vm := range fixtureLibrary().Maps {
+			m, _ := convertMap(vm, false, opts)
+			maps = append(maps, m)
+		}
+		disambiguateShortNames(maps, 0)
+		data, err := renderMaps(maps, outputOptions{Wrap: &OutputMetadata{Generator: "test"}, QuantizeBits: 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	first := render()
+	for i := 0; i < 5; i++ {
+		if !bytes.Equal(render(), first) {
+			t.Fatalf("run %d output differs from the first", i+2)
+		}
+	}
+}
+
+func TestBuildMapIndex(t *testing.T) {
+	maps := []OutputVideoMap{{
+		ID: "pct-mva", Name: "PCT MVA", ShortName: "MVA", DefaultVisible: true, Group: 1, Category: 3,
+		Features: []VideoMapFeature{
+			{Type: "line", Points: make([]Position, 3)},
+			{Type: "line", Points: make([]Position, 2)},
+		},
+	}}
+	got := buildMapIndex(maps)
+	want := mapIndexEntry{ID: "pct-mva", Name: "PCT MVA", ShortName: "MVA", DefaultVisible: true, Group: 1, Category: 3, Points: 5}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("buildMapIndex = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestBuildColorLegend(t *testing.T) {
+	maps := []OutputVideoMap{
+		{Name: "JRV North", Color: 4},
+		{Name: "PCT Coastlines", Color: 1},
+		{Name: "JRV South", Color: 4},
+		{Name: "PCT MVA", Color: 0},
+	}
+	got := buildColorLegend(maps)
+	want := []legendEntry{
+		{Color: 0, Count: 1, ExampleMapName: "PCT MVA"},
+		{Color: 1, Count: 1, ExampleMapName: "PCT Coastlines"},
+		{Color: 4, Count: 2, ExampleMapName: "JRV North"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("buildColorLegend = %+v, want %+v", got, want)
+	}
+}
+
+func TestStripDegenerateFeatures(t *testing.T) {
+	line := func(n int) VideoMapFeature { return VideoMapFeature{Type: "line", Points: make([]Position, n)} }
+	clean := OutputVideoMap{ID: "clean", Features: []VideoMapFeature{line(2), line(5)}}
+	if got, removed := stripDegenerateFeatures(clean, 2); removed != 0 || len(got.Features) != 2 {
+		t.Errorf("clean map: removed %d, kept %d features", removed, len(got.Features))
+	}
+
+	dirty := OutputVideoMap{ID: "dirty", Features: []VideoMapFeature{
+		line(0), line(1), line(3), line(4),
+		{Type: "arc", Arc: &ArcGeometry{RadiusNM: 5}},
+		{Type: "label", Text: "X", Position: &Position{}},
+	}}
+	for _, tc := range []struct{ min, removed int }{{2, 2}, {4, 3}} {
+		got, removed := stripDegenerateFeatures(dirty, tc.min)
+		if removed != tc.removed || len(got.Features) != len(dirty.Features)-tc.removed {
+			t.Errorf("min %d: removed %d, kept %d features", tc.min, removed, len(got.Features))
+		}
+		for _, f := range got.Features {
+			if f.Arc == nil && f.Position == nil && len(f.Points) < tc.min {
+				t.Errorf("min %d: kept a %d-point feature", tc.min, len(f.Points))
+			}
+		}
+	}
+	if len(dirty.Features) != 6 || len(dirty.Features[0].Points) != 0 {
+		t.Error("stripDegenerateFeatures modified its input")
+	}
+}
+
+func TestConvertMapGroupCategoryNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "categories.json")
+	if err := os.WriteFile(path, []byte(`{"3": "MVA", "0": "Geography",}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	categories, err := loadNameTable(path, "category")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := convertOptions{Precision: 5, CategoryNames: categories, GroupNames: map[int]string{1: "B"}}
+	out, _ := convertMap(VideoMap{Name: "PCT MVA", Group: 1, Category: 3}, false, opts)
+	if out.GroupName != "B" || out.CategoryName != "MVA" {
+		t.Errorf("names = %q/%q, want B/MVA", out.GroupName, out.CategoryName)
+	}
+	out, _ = convertMap(VideoMap{Name: "Other", Group: 0, Category: 7}, false, opts)
+	if out.GroupName != "" || out.CategoryName != "" {
+		t.Errorf("unlisted numbers: names = %q/%q, want empty", out.GroupName, out.CategoryName)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"mva": "MVA"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadNameTable(path, "category"); err == nil {
+		t.Error("non-integer key: expected error")
+	}
+}
+
+func TestConvertMapSafe(t *testing.T) {
+	good := VideoMap{Name: "Good", Lines: [][]Point2LL{{{-77, 37}, {-76, 37}}}}
+	if out, _, err := convertMapSafe(good, false, convertOptions{Precision: 5}); err != nil || len(out.Features) != 1 {
+		t.Errorf("good map: %d features, err %v", len(out.Features), err)
+	}
+
+	badLat := VideoMap{Name: "Bad", Lines: [][]Point2LL{{{-77, 37}, {-76, 95}}}}
+	if _, _, err := convertMapSafe(badLat, false, convertOptions{Precision: 5}); err == nil {
+		t.Error("latitude 95: expected an error")
+	}
+}
+
+func TestConvertMapAdaptivePrecision(t *testing.T) {
+	vm := VideoMap{
+		Name: "Airway",
+		Lines: [][]Point2LL{{
+			{-77.123456, 37.123456}, // ~7 nm from the center
+			{-76.123456, 38.123456}, // ~75 nm out
+		}},
+	}
+	opts := convertOptions{
+		Precision:              5,
+		ClipRegions:            []clipRegion{{Lat: 37, Lon: -77, RadiusNM: 100}},
+		AdaptiveInnerNM:        20,
+		AdaptiveOuterPrecision: 2,
+	}
+
+	out, _ := convertMap(vm, false, opts)
+
+	pts := out.Features[0].Points
+	if want := (Position{Lat: 37.12346, Lon: -77.12346}); pts[0] != want {
+		t.Errorf("inner point = %+v, want %+v (5 decimals)", pts[0], want)
+	}
+	if want := (Position{Lat: 38.12, Lon: -76.12}); pts[1] != want {
+		t.Errorf("outer point = %+v, want %+v (2 decimals)", pts[1], want)
+	}
+}
+
+func TestConvertMapZOrder(t *testing.T) {
+	line := [][]Point2LL{{{-77, 37}, {-76, 37}}}
+	coast := VideoMap{Name: "PCT Coastlines", Group: 1, Lines: line}
+	airway := VideoMap{Name: "PCT VAirway", Group: 1, Lines: line}
+	opts := convertOptions{Precision: 5, ZOrders: map[string]int{"PCT VAirway": 10}}
+
+	if out, _ := convertMap(airway, false, opts); out.ZOrder == nil || *out.ZOrder != 10 {
+		t.Errorf("listed map: zOrder = %v, want 10", out.ZOrder)
+	}
+	if out, _ := convertMap(coast, false, opts); out.ZOrder == nil || *out.ZOrder != 1 {
+		t.Errorf("unlisted map: zOrder = %v, want its Group 1", out.ZOrder)
+	}
+	if out, _ := convertMap(coast, false, convertOptions{Precision: 5}); out.ZOrder != nil {
+		t.Errorf("no -z-order-file: zOrder = %v, want none", *out.ZOrder)
+	}
+}
+
+func TestConvertMapNormalizeNames(t *testing.T) {
+	vm := VideoMap{Name: " JRV  North\tArrivals ", Lines: [][]Point2LL{{{-77, 37}, {-76, 37}}}}
+
+	out, _ := convertMap(vm, false, convertOptions{Precision: 5, NormalizeNames: true})
+	if out.Name != "JRV North Arrivals" || out.ID != "jrv-north-arrivals" || out.ShortName != "North Ar" {
+		t.Errorf("normalized: name %q, id %q, shortName %q", out.Name, out.ID, out.ShortName)
+	}
+	if out, _ := convertMap(vm, false, convertOptions{Precision: 5}); out.Name != vm.Name {
+		t.Errorf("default: name %q, want the Vice name unchanged", out.Name)
+	}
+}
+
+func TestConvertMapNilAndEmptyLines(t *testing.T) {
+	// gob decodes an empty slice as nil, so both forms reach convertMap
+	nilLines := VideoMap{Name: "Empty", Id: 6}
+	emptyLines := VideoMap{Name: "Empty", Id: 6, Lines: [][]Point2LL{}}
+
+	var outputs [][]byte
+	for _, vm := range []VideoMap{nilLines, emptyLines} {
+		out, stats := convertMap(vm, len(vm.Lines) > 0, convertOptions{Precision: 5, Centroid: true, BoundingCircle: true})
+		if out.DefaultVisible {
+			t.Errorf("Lines=%#v: DefaultVisible, want false for a map with no lines", vm.Lines)
+		}
+		if out.Features == nil || len(out.Features) != 0 || out.Centroid != nil || out.BoundingCircle != nil {
+			t.Errorf("Lines=%#v: features %#v, centroid %v, circle %v; want empty non-nil features only", vm.Lines, out.Features, out.Centroid, out.BoundingCircle)
+		}
+		if stats != (convertStats{}) {
+			t.Errorf("Lines=%#v: stats %+v, want zero", vm.Lines, stats)
+		}
+		data, err := json.Marshal(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("nil and empty Lines differ:\n%s\n%s", outputs[0], outputs[1])
+	}
+	if !bytes.Contains(outputs[0], []byte(`"features":[]`)) {
+		t.Errorf("features must serialize as [] (not null): %s", outputs[0])
+	}
+}
+
+func TestSamePointEpsilon(t *testing.T) {
+	// 0.0001° of latitude is 0.006 nm
+	if !samePoint(37, -77, 37, -77, 0) {
+		t.Error("identical points, epsilon 0: want same")
+	}
+	if samePoint(37, -77, 37.0001, -77, 0) {
+		t.Error("0.006 nm apart, epsilon 0: want different")
+	}
+	if !samePoint(37, -77, 37.0001, -77, 0.01) {
+		t.Error("0.006 nm apart, epsilon 0.01: want same")
+	}
+	if samePoint(37, -77, 37.0001, -77, 0.005) {
+		t.Error("0.006 nm apart, epsilon 0.005: want different")
+	}
+
+	ring := []Point2LL{{-77, 37}, {-76.9, 37}, {-76.9, 37.1}, {-77, 37.0001}}
+	if isClosed(ring, 0) || !isClosed(ring, 0.01) {
+		t.Errorf("near-closed ring: isClosed = %v at 0, %v at 0.01; want false, true", isClosed(ring, 0), isClosed(ring, 0.01))
+	}
+}
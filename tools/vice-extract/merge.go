@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Merging into an existing atc-sim map file (-merge-into)
+// Existing maps are kept as raw JSON so hand-authored fields this tool
+// doesn't model survive untouched. Converted maps replace existing ones
+// with the same ID in place; the rest are appended in output order.
+// ──────────────────────────────────────────────────────────────────────
+
+// loadMergeBase reads the maps of an atc-sim JSON file, either a bare map
+// array or a -wrap {metadata, maps} envelope
+func loadMergeBase(path string) ([]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var maps []json.RawMessage
+	if err := json.Unmarshal(data, &maps); err == nil {
+		return maps, nil
+	}
+	var wrapped struct {
+		Maps []json.RawMessage `json:"maps"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil || wrapped.Maps == nil {
+		return nil, fmt.Errorf("%s is not a map array or {\"maps\": [...]} file", path)
+	}
+	return wrapped.Maps, nil
+}
+
+// rawMapID returns a raw map's "id", or "" if it has none
+func rawMapID(m json.RawMessage) string {
+	var v struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(m, &v)
+	return v.ID
+}
+
+// mergeByID replaces base maps whose ID matches one in maps and appends
+// the others
+func mergeByID(base, maps []json.RawMessage) (merged []json.RawMessage, added, replaced int) {
+	merged = append([]json.RawMessage(nil), base...)
+	index := make(map[string]int, len(base))
+	for i, m := range base {
+		if id := rawMapID(m); id != "" {
+			if _, dup := index[id]; !dup {
+				index[id] = i
+			}
+		}
+	}
+	for _, m := range maps {
+		if i, ok := index[rawMapID(m)]; ok {
+			merged[i] = m
+			replaced++
+		} else {
+			merged = append(merged, m)
+			added++
+		}
+	}
+	return merged, added, replaced
+}
+
+// mergeMaps serializes the shaped map array v and merges it into base
+func mergeMaps(base []json.RawMessage, v any) ([]json.RawMessage, int, int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("marshal JSON: %w", err)
+	}
+	var maps []json.RawMessage
+	if err := json.Unmarshal(data, &maps); err != nil {
+		return nil, 0, 0, err
+	}
+	merged, added, replaced := mergeByID(base, maps)
+	return merged, added, replaced, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergeByID(t *testing.T) {
+	base := []json.RawMessage{
+		json.RawMessage(`{"id":"hand","name":"Hand drawn","features":[{"type":"label","text":"RIC"}]}`),
+		json.RawMessage(`{"id":"pct-mva","name":"Old MVA"}`),
+	}
+	maps := []json.RawMessage{
+		json.RawMessage(`{"id":"pct-mva","name":"PCT MVA"}`),
+		json.RawMessage(`{"id":"jrv-north","name":"JRV North"}`),
+	}
+
+	merged, added, replaced := mergeByID(base, maps)
+	if added != 1 || replaced != 1 {
+		t.Errorf("added, replaced = %d, %d; want 1, 1", added, replaced)
+	}
+	want := []string{
+		`{"id":"hand","name":"Hand drawn","features":[{"type":"label","text":"RIC"}]}`,
+		`{"id":"pct-mva","name":"PCT MVA"}`,
+		`{"id":"jrv-north","name":"JRV North"}`,
+	}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d maps, want %d", len(merged), len(want))
+	}
+	for i := range want {
+		if string(merged[i]) != want[i] {
+			t.Errorf("merged[%d] = %s, want %s", i, merged[i], want[i])
+		}
+	}
+	if string(base[1]) != `{"id":"pct-mva","name":"Old MVA"}` {
+		t.Error("mergeByID modified base")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+// ──────────────────────────────────────────────────────────────────────
+// Multi-line features (-multiline)
+// Each map's line features are folded into one feature of type
+// "multiline" whose "lines" holds every strip's points, in order, in
+// place of one "line" feature per strip. This trims the per-feature
+// overhead of maps made of many short strips. The multiline feature sits
+// where the map's first line was; arc and label features are kept as-is.
+// ──────────────────────────────────────────────────────────────────────
+
+// multilineMaps returns copies of maps with their line features merged
+// into a single "multiline" feature
+func multilineMaps(maps []OutputVideoMap) []OutputVideoMap {
+	out := make([]OutputVideoMap, len(maps))
+	for i, m := range maps {
+		features := make([]VideoMapFeature, 0, len(m.Features))
+		multi := -1
+		for _, f := range m.Features {
+			if f.Type != "line" || len(f.Points) == 0 {
+				features = append(features, f)
+				continue
+			}
+			if multi < 0 {
+				// Restrictions are per map, so the first line's is every line's
+				multi = len(features)
+				features = append(features, VideoMapFeature{Type: "multiline", Restriction: f.Restriction})
+			}
+			features[multi].Lines = append(features[multi].Lines, f.Points)
+		}
+		m.Features = features
+		out[i] = m
+	}
+	return out
+}
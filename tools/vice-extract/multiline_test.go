@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestMultilineMaps(t *testing.T) {
+	a := []Position{{Lat: 37, Lon: -77}, {Lat: 37.1, Lon: -77}}
+	b := []Position{{Lat: 38, Lon: -76}, {Lat: 38.1, Lon: -76}, {Lat: 38.2, Lon: -76}}
+	maps := []OutputVideoMap{
+		{ID: "m", Features: []VideoMapFeature{
+			{Type: "arc", Arc: &ArcGeometry{}},
+			{Type: "line", Points: a},
+			{Type: "line", Points: b},
+			{Type: "label", Text: "X", Position: &Position{Lat: 37.5, Lon: -76.5}},
+		}},
+		{ID: "empty"},
+	}
+
+	got := multilineMaps(maps)
+	f := got[0].Features
+	if len(f) != 3 || f[0].Type != "arc" || f[1].Type != "multiline" || f[2].Type != "label" {
+		t.Fatalf("features %+v, want arc, multiline, label", f)
+	}
+	if len(f[1].Lines) != 2 || len(f[1].Lines[0]) != 2 || len(f[1].Lines[1]) != 3 || f[1].Points != nil {
+		t.Errorf("multiline lines %v points %v, want both strips in order and no points", f[1].Lines, f[1].Points)
+	}
+	if len(got[1].Features) != 0 {
+		t.Errorf("map without lines got features %+v", got[1].Features)
+	}
+	if len(maps[0].Features) != 4 {
+		t.Error("multilineMaps modified its input")
+	}
+}
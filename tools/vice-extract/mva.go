@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// MVA altitude band filtering (-mva-min, -mva-max)
+// Vice keeps an MVA map's altitude only in its restriction text (e.g.
+// ["MVA", "030"]), and restrictions are per map, so the band keeps or
+// drops whole maps. Only maps that look like MVA maps are considered.
+// ──────────────────────────────────────────────────────────────────────
+
+// isMVAMap reports whether vm is an MVA map: "MVA" in its name, label, or
+// restriction text
+func isMVAMap(vm VideoMap) bool {
+	for _, s := range []string{vm.Name, vm.Label, vm.Restriction.Text[0], vm.Restriction.Text[1]} {
+		if strings.Contains(strings.ToUpper(s), "MVA") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMVAAltitude returns the altitude in feet from the first number in
+// an MVA restriction text. Up to three digits are hundreds of feet, as on
+// the scope ("030", "45"); longer numbers are feet ("3000", "3,000 FT").
+func parseMVAAltitude(text [2]string) (int, bool) {
+	for _, line := range text {
+		digits := ""
+		for i, r := range line {
+			if unicode.IsDigit(r) {
+				digits += string(r)
+			} else if r == ',' && digits != "" && i+1 < len(line) && unicode.IsDigit(rune(line[i+1])) {
+				continue // thousands separator
+			} else if digits != "" {
+				break
+			}
+		}
+		if digits == "" {
+			continue
+		}
+		v, err := strconv.Atoi(digits)
+		if err != nil {
+			continue
+		}
+		if len(digits) <= 3 {
+			v *= 100
+		}
+		return v, true
+	}
+	return 0, false
+}
+
+// mvaBand is an inclusive altitude range in feet; Max 0 means no ceiling
+type mvaBand struct{ Min, Max int }
+
+func (b mvaBand) active() bool { return b.Min > 0 || b.Max > 0 }
+
+func (b mvaBand) contains(alt int) bool {
+	return alt >= b.Min && (b.Max == 0 || alt <= b.Max)
+}
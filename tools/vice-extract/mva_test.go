@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseMVAAltitude(t *testing.T) {
+	tests := []struct {
+		text [2]string
+		want int
+		ok   bool
+	}{
+		{[2]string{"MVA", "030"}, 3000, true},
+		{[2]string{"45", ""}, 4500, true},
+		{[2]string{"MVA 3,000 FT", ""}, 3000, true},
+		{[2]string{"", "2500"}, 2500, true},
+		{[2]string{"MVA", "TBD"}, 0, false},
+		{[2]string{"", ""}, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseMVAAltitude(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseMVAAltitude(%q) = %d, %v; want %d, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMVABand(t *testing.T) {
+	b := mvaBand{Min: 2000, Max: 4000}
+	for alt, want := range map[int]bool{1900: false, 2000: true, 4000: true, 4100: false} {
+		if b.contains(alt) != want {
+			t.Errorf("%+v.contains(%d) = %v, want %v", b, alt, !want, want)
+		}
+	}
+	if !(mvaBand{Min: 3000}).contains(90000) {
+		t.Error("Max 0 should mean no ceiling")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// External post-processing (-post-command)
+// Each rendered output file is piped through a program before it is
+// written: the file on stdin, the replacement on stdout. The command is
+// split on whitespace and run directly, not through a shell, so it
+// behaves the same everywhere; wrap it in a script for pipes or quoting.
+// ──────────────────────────────────────────────────────────────────────
+
+// runPostCommand runs command with data on stdin and returns its stdout.
+// The command's stderr passes through to ours. A nonzero exit is an error.
+func runPostCommand(command string, data []byte) ([]byte, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("-post-command is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("-post-command %q: %w", command, err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestRunPostCommand(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr not available")
+	}
+	got, err := runPostCommand("tr a-z A-Z", []byte(`{"id":"a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"ID":"A"}` {
+		t.Errorf("got %s, want {\"ID\":\"A\"}", got)
+	}
+
+	if _, err := exec.LookPath("false"); err == nil {
+		if _, err := runPostCommand("false", []byte("{}")); err == nil {
+			t.Error("nonzero exit: expected an error")
+		}
+	}
+	if _, err := runPostCommand("  ", nil); err == nil {
+		t.Error("empty command: expected an error")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Profiling (-cpuprofile, -memprofile)
+// Standard pprof output for performance work on large dumps: the CPU
+// profile covers the whole run and the heap profile is a snapshot taken
+// at completion. Inspect either with `go tool pprof`. Both are written
+// when main returns; a run that exits with an error writes neither.
+// ──────────────────────────────────────────────────────────────────────
+
+// startProfiling starts CPU profiling to cpuPath (if set) and returns a
+// stop function that ends it and writes a heap profile to memPath (if set)
+func startProfiling(cpuPath, memPath string) (func() error, error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("start CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return err
+			}
+		}
+		if memPath == "" {
+			return nil
+		}
+		f, err := os.Create(memPath)
+		if err != nil {
+			return err
+		}
+		runtime.GC() // up-to-date allocation statistics
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("write heap profile: %w", err)
+		}
+		return f.Close()
+	}, nil
+}
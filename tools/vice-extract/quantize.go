@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Coordinate quantization (-quantize), as in TopoJSON
+// The bounding box of a file's points maps onto a 2^bits integer grid per
+// axis. Quantized features carry "coords": [[x, y], ...] instead of
+// "points", and the -wrap envelope gets a top-level "transform":
+//
+//   lon = x * scale[0] + translate[0]
+//   lat = y * scale[1] + translate[1]
+//
+// Reconstruction is within half a grid step (scale/2) on each axis. Arc
+// features keep their lat/lon center.
+// ──────────────────────────────────────────────────────────────────────
+
+// QuantizeTransform maps integer grid coordinates back to lon/lat
+type QuantizeTransform struct {
+	Scale     [2]float64 `json:"scale"`     // degrees per grid step: lon, lat
+	Translate [2]float64 `json:"translate"` // lon, lat of grid (0, 0)
+}
+
+// newQuantizeTransform fits a 2^bits grid to the extent of maps' points
+func newQuantizeTransform(maps []OutputVideoMap, bits int) QuantizeTransform {
+	minLon, minLat := math.Inf(1), math.Inf(1)
+	maxLon, maxLat := math.Inf(-1), math.Inf(-1)
+	for _, m := range maps {
+		for _, f := range m.Features {
+			for _, p := range f.Points {
+				minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+				minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+			}
+		}
+	}
+	if math.IsInf(minLon, 1) {
+		return QuantizeTransform{Scale: [2]float64{1, 1}}
+	}
+
+	steps := float64(uint64(1)<<bits - 1)
+	scale := func(span float64) float64 {
+		if span == 0 {
+			return 1 // every point sits at 0
+		}
+		return span / steps
+	}
+	return QuantizeTransform{
+		Scale:     [2]float64{scale(maxLon - minLon), scale(maxLat - minLat)},
+		Translate: [2]float64{minLon, minLat},
+	}
+}
+
+func (t QuantizeTransform) quantize(p Position) [2]int64 {
+	return [2]int64{
+		int64(math.Round((p.Lon - t.Translate[0]) / t.Scale[0])),
+		int64(math.Round((p.Lat - t.Translate[1]) / t.Scale[1])),
+	}
+}
+
+func (t QuantizeTransform) position(q [2]int64) Position {
+	return Position{
+		Lat: float64(q[1])*t.Scale[1] + t.Translate[1],
+		Lon: float64(q[0])*t.Scale[0] + t.Translate[0],
+	}
+}
+
+// quantizeMaps returns copies of maps with each feature's Points replaced
+// by grid Coords
+func quantizeMaps(maps []OutputVideoMap, t QuantizeTransform) []OutputVideoMap {
+	out := make([]OutputVideoMap, len(maps))
+	for i, m := range maps {
+		features := make([]VideoMapFeature, len(m.Features))
+		for j, f := range m.Features {
+			if len(f.Points) > 0 {
+				f.Coords = make([][2]int64, len(f.Points))
+				for k, p := range f.Points {
+					f.Coords[k] = t.quantize(p)
+				}
+				f.Points = nil
+			}
+			features[j] = f
+		}
+		m.Features = features
+		out[i] = m
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantizeRoundTrip(t *testing.T) {
+	var pts []Position
+	for i := 0; i < 100; i++ {
+		pts = append(pts, Position{
+			Lat: 36.5 + float64(i)*0.0173,
+			Lon: -78.2 + math.Sin(float64(i))*0.9,
+		})
+	}
+	maps := []OutputVideoMap{{ID: "m", Features: []VideoMapFeature{{Type: "line", Points: pts}}}}
+
+	for _, bits := range []int{8, 16, 24} {
+		tr := newQuantizeTransform(maps, bits)
+		q := quantizeMaps(maps, tr)
+		if maps[0].Features[0].Points == nil {
+			t.Fatal("quantizeMaps modified its input")
+		}
+		coords := q[0].Features[0].Coords
+		if len(coords) != len(pts) || q[0].Features[0].Points != nil {
+			t.Fatalf("bits=%d: got %d coords and %d points, want %d coords only",
+				bits, len(coords), len(q[0].Features[0].Points), len(pts))
+		}
+		maxGrid := int64(1)<<bits - 1
+		for i, c := range coords {
+			if c[0] < 0 || c[1] < 0 || c[0] > maxGrid || c[1] > maxGrid {
+				t.Fatalf("bits=%d: coord %v outside [0, %d]", bits, c, maxGrid)
+			}
+			got := tr.position(c)
+			if math.Abs(got.Lon-pts[i].Lon) > tr.Scale[0]/2+1e-12 ||
+				math.Abs(got.Lat-pts[i].Lat) > tr.Scale[1]/2+1e-12 {
+				t.Errorf("bits=%d: %+v round-tripped to %+v (scale %v)", bits, pts[i], got, tr.Scale)
+			}
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Read-only analysis reports over the converted maps
+// ──────────────────────────────────────────────────────────────────────
+
+// densityCell is one lat/lon grid cell and the number of points in it
+type densityCell struct {
+	Lat, Lon float64 // cell center
+	Points   int
+}
+
+// densityCells bins every emitted point into a grid of cellDeg-sized cells
+// and returns the occupied cells, densest first.
+func densityCells(maps []OutputVideoMap, cellDeg float64) []densityCell {
+	type key struct{ row, col int }
+	counts := make(map[key]int)
+	for _, m := range maps {
+		for _, f := range m.Features {
+			for _, p := range f.Points {
+				k := key{int(math.Floor(p.Lat / cellDeg)), int(math.Floor(p.Lon / cellDeg))}
+				counts[k]++
+			}
+		}
+	}
+
+	cells := make([]densityCell, 0, len(counts))
+	for k, n := range counts {
+		cells = append(cells, densityCell{
+			Lat:    (float64(k.row) + 0.5) * cellDeg,
+			Lon:    (float64(k.col) + 0.5) * cellDeg,
+			Points: n,
+		})
+	}
+	// Ties broken by position so the report is deterministic
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Points != cells[j].Points {
+			return cells[i].Points > cells[j].Points
+		}
+		if cells[i].Lat != cells[j].Lat {
+			return cells[i].Lat > cells[j].Lat
+		}
+		return cells[i].Lon < cells[j].Lon
+	})
+	return cells
+}
+
+// formatDensityReport renders the top-N densest cells as a text table
+func formatDensityReport(maps []OutputVideoMap, cellDeg float64, top int) []byte {
+	cells := densityCells(maps, cellDeg)
+	total := 0
+	for _, c := range cells {
+		total += c.Points
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Density report: %.3f° cells, %d occupied, %d points\n", cellDeg, len(cells), total)
+	if top > 0 && top < len(cells) {
+		cells = cells[:top]
+	}
+	for i, c := range cells {
+		pct := 0.0
+		if total > 0 {
+			pct = float64(c.Points) / float64(total) * 100
+		}
+		fmt.Fprintf(&b, "  %3d. (%8.3f, %8.3f)  %7d points  (%.1f%%)\n", i+1, c.Lat, c.Lon, c.Points, pct)
+	}
+	return b.Bytes()
+}
+
+// stripHash hashes a feature's rounded point sequence
+func stripHash(points []Position) uint64 {
+	h := fnv.New64a()
+	var buf [16]byte
+	for _, p := range points {
+		binary.LittleEndian.PutUint64(buf[:8], math.Float64bits(p.Lat))
+		binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(p.Lon))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// formatDuplicateStripReport lists strips whose exact point sequence is
+// emitted by more than one map, grouped by the set of maps sharing them.
+// Arc and label features carry no points and are not strips.
+func formatDuplicateStripReport(maps []OutputVideoMap) []byte {
+	type stripInfo struct {
+		maps   []string // names of maps containing the strip, in output order
+		points int
+	}
+	strips := make(map[uint64]*stripInfo)
+	for _, m := range maps {
+		for _, f := range m.Features {
+			if len(f.Points) == 0 {
+				continue
+			}
+			h := stripHash(f.Points)
+			si := strips[h]
+			if si == nil {
+				si = &stripInfo{points: len(f.Points)}
+				strips[h] = si
+			}
+			if n := len(si.maps); n == 0 || si.maps[n-1] != m.Name {
+				si.maps = append(si.maps, m.Name)
+			}
+		}
+	}
+
+	type group struct {
+		strips, points int
+	}
+	groups := make(map[string]*group)
+	shared, redundantPoints := 0, 0
+	for _, si := range strips {
+		if len(si.maps) < 2 {
+			continue
+		}
+		shared++
+		redundantPoints += si.points * (len(si.maps) - 1)
+		key := strings.Join(si.maps, ", ")
+		g := groups[key]
+		if g == nil {
+			g = &group{}
+			groups[key] = g
+		}
+		g.strips++
+		g.points += si.points
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Duplicate strips: %d strips appear in more than one map (%d redundant points)\n", shared, redundantPoints)
+	keys := sortedKeys(groups)
+	sort.SliceStable(keys, func(i, j int) bool { return groups[keys[i]].points > groups[keys[j]].points })
+	for _, key := range keys {
+		g := groups[key]
+		fmt.Fprintf(&b, "  %5d strips, %7d points  shared by: %s\n", g.strips, g.points, key)
+	}
+	return b.Bytes()
+}
+
+// formatBBoxReport summarizes the overall extent of the source geometry
+// (before clipping or any other conversion). NaN/Inf points are ignored.
+func formatBBoxReport(maps []VideoMap) []byte {
+	minLat, minLon := math.Inf(1), math.Inf(1)
+	maxLat, maxLon := math.Inf(-1), math.Inf(-1)
+	points := 0
+	for _, vm := range maps {
+		for _, strip := range vm.Lines {
+			for _, p := range strip {
+				if !isFinite(p[0]) || !isFinite(p[1]) {
+					continue
+				}
+				lat, lon := float64(p[1]), float64(p[0])
+				minLat, maxLat = math.Min(minLat, lat), math.Max(maxLat, lat)
+				minLon, maxLon = math.Min(minLon, lon), math.Max(maxLon, lon)
+				points++
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Bounding box of %d maps (%d points, before clipping):\n", len(maps), points)
+	if points == 0 {
+		fmt.Fprintf(&b, "  (no points)\n")
+		return b.Bytes()
+	}
+	centerLat, centerLon := (minLat+maxLat)/2, (minLon+maxLon)/2
+	fmt.Fprintf(&b, "  Lat: %10.5f .. %10.5f\n", minLat, maxLat)
+	fmt.Fprintf(&b, "  Lon: %10.5f .. %10.5f\n", minLon, maxLon)
+	fmt.Fprintf(&b, "  Center: (%.5f, %.5f)\n", centerLat, centerLon)
+	fmt.Fprintf(&b, "  Radius from center to corner: %.1f nm\n", distanceNM(centerLat, centerLon, maxLat, maxLon))
+	return b.Bytes()
+}
+
+// sweepRow is one tolerance in a -simplify-sweep table
+type sweepRow struct {
+	Tolerance       float64 // nm
+	Points          int     // total converted points
+	MaxDisplacement float64 // nm, worst point moved by RDP
+}
+
+// simplifySweep converts maps at each tolerance. Points counts the full
+// conversion (clipping and all); MaxDisplacement measures RDP alone on
+// each source strip, split at NaN/Inf points like convertMap does.
+// CategoryTolerances are ignored so both columns describe the swept
+// tolerance applied to every map.
+func simplifySweep(maps []VideoMap, opts convertOptions, tolerances []float64) []sweepRow {
+	rows := make([]sweepRow, len(tolerances))
+	for i, tol := range tolerances {
+		o := opts
+		o.SimplifyTolerance = tol
+		o.CategoryTolerances = nil
+		row := sweepRow{Tolerance: tol}
+		for _, vm := range maps {
+			m, _, _ := convertMapSafe(vm, false, o) // failures are reported by the main pass
+			row.Points += countPoints(m)
+			for _, strip := range vm.Lines {
+				parts, _ := splitInvalidPoints(strip)
+				for _, part := range parts {
+					d := maxDisplacementNM(part, simplifyRDP(part, tol))
+					row.MaxDisplacement = math.Max(row.MaxDisplacement, d)
+				}
+			}
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// formatSimplifySweep renders simplifySweep rows as a table, with each
+// row's points as a share of the first row's. categoryOverrides notes that
+// -simplify-by-category tolerances were set but not swept.
+func formatSimplifySweep(rows []sweepRow, categoryOverrides bool) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Simplification sweep:\n")
+	if categoryOverrides {
+		fmt.Fprintf(&b, "  (-simplify-by-category ignored: every map uses the swept tolerance)\n")
+	}
+	fmt.Fprintf(&b, "  %10s  %10s  %7s  %16s\n", "tolerance", "points", "kept", "max displacement")
+	for _, r := range rows {
+		kept := 100.0
+		if rows[0].Points > 0 {
+			kept = float64(r.Points) / float64(rows[0].Points) * 100
+		}
+		fmt.Fprintf(&b, "  %7.3f nm  %10d  %6.1f%%  %13.4f nm\n", r.Tolerance, r.Points, kept, r.MaxDisplacement)
+	}
+	return b.Bytes()
+}
+
+// mapDecision records why one source map was included or excluded
+// (-explain). Methods are no-ops on a nil receiver so the pipeline can
+// call them unconditionally.
+type mapDecision struct {
+	Id      int
+	Name    string
+	Steps   []string
+	Verdict string
+}
+
+func (d *mapDecision) note(format string, args ...any) {
+	if d != nil {
+		d.Steps = append(d.Steps, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *mapDecision) exclude(format string, args ...any) {
+	if d != nil {
+		d.note(format, args...)
+		d.Verdict = "EXCLUDED"
+	}
+}
+
+func (d *mapDecision) include(format string, args ...any) {
+	if d != nil {
+		d.Verdict = "INCLUDED (" + fmt.Sprintf(format, args...) + ")"
+	}
+}
+
+// formatExplainReport lists each map's decisions in source order
+func formatExplainReport(decisions []*mapDecision) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Explain (%d source maps):\n", len(decisions))
+	for _, d := range decisions {
+		fmt.Fprintf(&b, "  [%3d] %s\n", d.Id, d.Name)
+		for _, s := range d.Steps {
+			fmt.Fprintf(&b, "        %s\n", s)
+		}
+		fmt.Fprintf(&b, "        => %s\n", d.Verdict)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDuplicateStripReportSkipsPointlessFeatures(t *testing.T) {
+	shared := []Position{{Lat: 37, Lon: -77}, {Lat: 37.1, Lon: -77}}
+	maps := []OutputVideoMap{
+		{Name: "A", Features: []VideoMapFeature{
+			{Type: "label", Text: "A", Position: &Position{Lat: 37, Lon: -77}},
+			{Type: "arc", Arc: &ArcGeometry{RadiusNM: 5}},
+		}},
+		{Name: "B", Features: []VideoMapFeature{
+			{Type: "label", Text: "B", Position: &Position{Lat: 38, Lon: -76}},
+			{Type: "line", Points: shared},
+		}},
+	}
+	if got := string(formatDuplicateStripReport(maps)); !strings.HasPrefix(got, "Duplicate strips: 0 strips") {
+		t.Errorf("labels and arcs reported as shared strips:\n%s", got)
+	}
+
+	maps[0].Features = append(maps[0].Features, VideoMapFeature{Type: "line", Points: shared})
+	if got := string(formatDuplicateStripReport(maps)); !strings.Contains(got, "1 strips appear") || !strings.Contains(got, "shared by: A, B") {
+		t.Errorf("shared line not reported:\n%s", got)
+	}
+}
+
+func TestSimplifySweepIgnoresCategoryTolerances(t *testing.T) {
+	// A gentle zigzag: RDP at 0.5 nm keeps only the endpoints
+	var strip []Point2LL
+	for i := 0; i <= 10; i++ {
+		strip = append(strip, Point2LL{float32(-77 + 0.01*float64(i)), float32(37 + 0.0005*float64(i%2))})
+	}
+	maps := []VideoMap{{Name: "Zig", Category: 3, Lines: [][]Point2LL{strip}}}
+	opts := convertOptions{Precision: 5, CategoryTolerances: map[int]float64{3: 0}}
+
+	rows := simplifySweep(maps, opts, []float64{0, 0.5})
+	if rows[0].Points != 11 || rows[1].Points != 2 || rows[1].MaxDisplacement == 0 {
+		t.Errorf("rows %+v: want 11 then 2 points at the swept tolerance", rows)
+	}
+	if got := string(formatSimplifySweep(rows, true)); !strings.Contains(got, "-simplify-by-category ignored") {
+		t.Errorf("header should note ignored category tolerances:\n%s", got)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Vice scenario group JSON
+// The gob manifest is only a set of map names; the per-position map lists
+// and default visibility live in the scenario group's stars_config.
+// Source: github.com/mmp/vice/sim/stars.go (STARSFacilityAdaptation)
+// ──────────────────────────────────────────────────────────────────────
+
+// ViceScenarioGroup is the subset of a Vice scenario group file we read
+type ViceScenarioGroup struct {
+	STARSConfig struct {
+		VideoMapFile      string                          `json:"video_map_file"`
+		VideoMaps         []string                        `json:"video_maps"`
+		ControllerConfigs map[string]ViceControllerConfig `json:"controller_configs"`
+	} `json:"stars_config"`
+}
+
+// ViceControllerConfig lists the maps available to one position (TCP)
+type ViceControllerConfig struct {
+	VideoMaps   []string `json:"video_maps"`
+	DefaultMaps []string `json:"default_maps"`
+}
+
+func loadScenario(path string) (*ViceScenarioGroup, error) {
+	var sg ViceScenarioGroup
+	if err := loadJSONConfig(path, &sg); err != nil {
+		return nil, err
+	}
+	return &sg, nil
+}
+
+// scenarioVisibility collects default visibility across the positions of a
+// scenario group: a map is visible if any position lists it in default_maps.
+// mentioned holds every map some position lists at all; maps outside it are
+// left to the usual heuristic.
+func scenarioVisibility(configs map[string]ViceControllerConfig) (visible, mentioned map[string]bool) {
+	visible = make(map[string]bool)
+	mentioned = make(map[string]bool)
+	for _, cc := range configs {
+		for _, name := range cc.VideoMaps {
+			mentioned[name] = true
+		}
+		for _, name := range cc.DefaultMaps {
+			mentioned[name] = true
+			visible[name] = true
+		}
+	}
+	return visible, mentioned
+}
+
+// scenarioPosition returns one position's config. pos may be empty when
+// the scenario has exactly one position.
+func scenarioPosition(sg *ViceScenarioGroup, pos string) (string, ViceControllerConfig, error) {
+	configs := sg.STARSConfig.ControllerConfigs
+	if pos == "" {
+		if len(configs) != 1 {
+			return "", ViceControllerConfig{}, fmt.Errorf("scenario has %d positions (%s); choose one with -scenario-position",
+				len(configs), strings.Join(sortedKeys(configs), ", "))
+		}
+		pos = sortedKeys(configs)[0]
+	}
+	cc, ok := configs[pos]
+	if !ok {
+		return "", ViceControllerConfig{}, fmt.Errorf("no position %q in scenario (have %s)", pos, strings.Join(sortedKeys(configs), ", "))
+	}
+	return pos, cc, nil
+}
+
+// runScenario writes one output file per scenario position into outDir,
+// each holding that position's maps in its configured order with
+// DefaultVisible taken from the position's default_maps. Maps that fail
+// to convert are skipped; with failOnSkip that is an error, returned
+// before any position is written.
+func runScenario(path string, vmLib *VideoMapLibrary, opts convertOptions, outDir string, oo outputOptions, failOnSkip bool) error {
+	sg, err := loadScenario(path)
+	if err != nil {
+		return err
+	}
+	configs := sg.STARSConfig.ControllerConfigs
+	if len(configs) == 0 {
+		return fmt.Errorf("scenario %s has no stars_config.controller_configs", path)
+	}
+	byName := make(map[string]VideoMap, len(vmLib.Maps))
+	for _, vm := range vmLib.Maps {
+		if _, dup := byName[vm.Name]; !dup {
+			byName[vm.Name] = vm
+		}
+	}
+
+	positions := make([]string, 0, len(configs))
+	for pos := range configs {
+		positions = append(positions, pos)
+	}
+	sort.Strings(positions)
+
+	fmt.Fprintf(stderr, "Scenario %s: %d positions\n\n", path, len(positions))
+	skipped := 0
+	type positionOutput struct {
+		maps    []OutputVideoMap
+		visible int
+	}
+	outputs := make([]positionOutput, len(positions))
+	for i, pos := range positions {
+		cc := configs[pos]
+		defaults := make(map[string]bool, len(cc.DefaultMaps))
+		for _, name := range cc.DefaultMaps {
+			defaults[name] = true
+		}
+
+		outputMaps := make([]OutputVideoMap, 0, len(cc.VideoMaps))
+		visible := 0
+		for _, name := range cc.VideoMaps {
+			vm, ok := byName[name]
+			if !ok {
+				fmt.Fprintf(stderr, "  WARNING: [%s] map '%s' NOT FOUND in video map file\n", pos, name)
+				continue
+			}
+			outMap, stats, err := convertMapSafe(vm, defaults[name], opts)
+			if err != nil {
+				fmt.Fprintf(stderr, "  WARNING: [%s] skipping '%s': %v\n", pos, name, err)
+				skipped++
+				continue
+			}
+			if stats.InvalidPoints > 0 {
+				fmt.Fprintf(stderr, "  WARNING: [%s] %s: dropped %d NaN/Inf points\n", pos, name, stats.InvalidPoints)
+			}
+			if stats.Degenerate > 0 {
+				fmt.Fprintf(stderr, "  WARNING: [%s] %s: removed %d features with fewer than %d points\n", pos, name, stats.Degenerate, max(opts.MinStripPoints, 2))
+			}
+			if outMap.DefaultVisible {
+				visible++
+			}
+			outputMaps = append(outputMaps, outMap)
+		}
+
+		for _, c := range disambiguateShortNames(outputMaps, opts.ShortNameLength) {
+			fmt.Fprintf(stderr, "  WARNING: [%s] ShortName collision: %s\n", pos, c)
+		}
+		outputs[i] = positionOutput{maps: outputMaps, visible: visible}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(stderr, "\nWARNING: Skipped %d maps that failed to convert\n", skipped)
+		if failOnSkip {
+			return fmt.Errorf("%d maps failed to convert (-fail-on-skip); nothing written", skipped)
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	for i, pos := range positions {
+		outPath := filepath.Join(outDir, slugify(pos, opts.SlugStrip)+".json")
+		n, err := writeMaps(outPath, outputs[i].maps, oo)
+		if err != nil {
+			return fmt.Errorf("position %s: %w", pos, err)
+		}
+		fmt.Fprintf(stderr, "  %-6s %3d maps (%d default visible) -> %s (%.2f MB)\n",
+			pos, len(outputs[i].maps), outputs[i].visible, outPath, float64(n)/1024/1024)
+	}
+	return nil
+}
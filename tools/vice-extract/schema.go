@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// JSON Schema self-check (-schema)
+// A small stdlib-only validator for the keywords a data-shape schema
+// needs: type, enum, const, properties, required, additionalProperties,
+// items, min/maxItems, minimum/maximum (and exclusive forms), min/maxLength,
+// pattern, allOf/anyOf/oneOf/not, and local "$ref"s ("#/$defs/...").
+// Annotation keywords are ignored. Any other keyword is rejected when the
+// schema is loaded, so an unsupported constraint can never pass silently.
+// ──────────────────────────────────────────────────────────────────────
+
+// schemaAnnotations are keywords that don't constrain instances
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "$defs": true, "definitions": true,
+	"title": true, "description": true, "default": true, "examples": true,
+	"format": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// schemaKeywords are the constraint keywords validate understands
+var schemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true, "$ref": true,
+}
+
+// jsonSchema is a loaded schema document
+type jsonSchema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+}
+
+// parseJSONSchema parses a schema and checks every (sub)schema uses only
+// supported keywords, with valid "$ref"s and patterns
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	s := &jsonSchema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.check(root, "#"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadJSONSchema reads a schema file (lenient JSON, like other configs)
+func loadJSONSchema(path string) (*jsonSchema, error) {
+	var raw json.RawMessage
+	if err := loadJSONConfig(path, &raw); err != nil {
+		return nil, err
+	}
+	s, err := parseJSONSchema(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *jsonSchema) check(node any, at string) error {
+	if _, ok := node.(bool); ok {
+		return nil
+	}
+	obj, ok := node.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%s: schema must be an object or boolean", at)
+	}
+	for _, k := range sortedKeys(obj) {
+		v := obj[k]
+		switch {
+		case k == "$defs" || k == "definitions" || k == "properties":
+			defs, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s/%s: must be an object", at, k)
+			}
+			for _, name := range sortedKeys(defs) {
+				if err := s.check(defs[name], at+"/"+k+"/"+name); err != nil {
+					return err
+				}
+			}
+		case schemaAnnotations[k]:
+		case !schemaKeywords[k]:
+			return fmt.Errorf("%s: unsupported schema keyword %q", at, k)
+		case k == "items" || k == "not":
+			if err := s.check(v, at+"/"+k); err != nil {
+				return err
+			}
+		case k == "additionalProperties":
+			if err := s.check(v, at+"/"+k); err != nil {
+				return err
+			}
+		case k == "allOf" || k == "anyOf" || k == "oneOf":
+			list, ok := v.([]any)
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s/%s: must be a non-empty array", at, k)
+			}
+			for i, sub := range list {
+				if err := s.check(sub, fmt.Sprintf("%s/%s/%d", at, k, i)); err != nil {
+					return err
+				}
+			}
+		case k == "$ref":
+			ref, _ := v.(string)
+			if _, err := s.resolve(ref); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		case k == "pattern":
+			p, _ := v.(string)
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("%s/pattern: %w", at, err)
+			}
+			s.patterns[p] = re
+		}
+	}
+	return nil
+}
+
+// resolve follows a local JSON pointer reference ("#", "#/$defs/x")
+func (s *jsonSchema) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("$ref %q: only local references (#/...) are supported", ref)
+	}
+	node := s.root
+	for _, tok := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[tok]
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("$ref %q: no such element", ref)
+			}
+			node = n[i]
+		default:
+			node = nil
+		}
+		if node == nil {
+			return nil, fmt.Errorf("$ref %q: no such element", ref)
+		}
+	}
+	return node, nil
+}
+
+// validate checks a JSON document against the schema and returns one
+// "path: problem" line per violation (paths are JSON pointers), sorted
+func (s *jsonSchema) validate(data []byte) ([]string, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var errs []string
+	s.validateNode(s.root, doc, "", &errs)
+	sort.Strings(errs)
+	return errs, nil
+}
+
+func (s *jsonSchema) validateNode(schema, v any, path string, errs *[]string) {
+	fail := func(format string, args ...any) {
+		p := path
+		if p == "" {
+			p = "/"
+		}
+		*errs = append(*errs, p+": "+fmt.Sprintf(format, args...))
+	}
+
+	if b, ok := schema.(bool); ok {
+		if !b {
+			fail("not allowed")
+		}
+		return
+	}
+	sch := schema.(map[string]any)
+
+	if ref, ok := sch["$ref"].(string); ok {
+		target, _ := s.resolve(ref) // checked at load
+		s.validateNode(target, v, path, errs)
+	}
+
+	if t, ok := sch["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, x := range t {
+				if name, ok := x.(string); ok {
+					types = append(types, name)
+				}
+			}
+		}
+		matched := false
+		for _, name := range types {
+			if jsonTypeIs(v, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("got %s, want %s", jsonTypeName(v), strings.Join(types, " or "))
+			return // other keywords would only repeat the mismatch
+		}
+	}
+	if enum, ok := sch["enum"].([]any); ok && !slicesContainsJSON(enum, v) {
+		fail("value %s not in enum", compactJSON(v))
+	}
+	if c, ok := sch["const"]; ok && !reflect.DeepEqual(c, v) {
+		fail("value %s, want %s", compactJSON(v), compactJSON(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := sch["properties"].(map[string]any)
+		if req, ok := sch["required"].([]any); ok {
+			for _, r := range req {
+				if name, ok := r.(string); ok {
+					if _, present := v[name]; !present {
+						fail("missing required property %q", name)
+					}
+				}
+			}
+		}
+		for _, k := range sortedKeys(v) {
+			child := path + "/" + strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+			if ps, ok := props[k]; ok {
+				s.validateNode(ps, v[k], child, errs)
+			} else if ap, ok := sch["additionalProperties"]; ok {
+				s.validateNode(ap, v[k], child, errs)
+			}
+		}
+	case []any:
+		if items, ok := sch["items"]; ok {
+			for i, item := range v {
+				s.validateNode(items, item, path+"/"+strconv.Itoa(i), errs)
+			}
+		}
+		if n, ok := sch["minItems"].(float64); ok && float64(len(v)) < n {
+			fail("%d items, want at least %g", len(v), n)
+		}
+		if n, ok := sch["maxItems"].(float64); ok && float64(len(v)) > n {
+			fail("%d items, want at most %g", len(v), n)
+		}
+	case float64:
+		if n, ok := sch["minimum"].(float64); ok && v < n {
+			fail("%g is below minimum %g", v, n)
+		}
+		if n, ok := sch["maximum"].(float64); ok && v > n {
+			fail("%g is above maximum %g", v, n)
+		}
+		if n, ok := sch["exclusiveMinimum"].(float64); ok && v <= n {
+			fail("%g is not above %g", v, n)
+		}
+		if n, ok := sch["exclusiveMaximum"].(float64); ok && v >= n {
+			fail("%g is not below %g", v, n)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n, ok := sch["minLength"].(float64); ok && float64(length) < n {
+			fail("length %d, want at least %g", length, n)
+		}
+		if n, ok := sch["maxLength"].(float64); ok && float64(length) > n {
+			fail("length %d, want at most %g", length, n)
+		}
+		if p, ok := sch["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+			fail("%q does not match pattern %q", v, p)
+		}
+	}
+
+	if all, ok := sch["allOf"].([]any); ok {
+		for _, sub := range all {
+			s.validateNode(sub, v, path, errs)
+		}
+	}
+	if anyOf, ok := sch["anyOf"].([]any); ok && s.countMatches(anyOf, v, path) == 0 {
+		fail("matches none of anyOf")
+	}
+	if oneOf, ok := sch["oneOf"].([]any); ok {
+		if n := s.countMatches(oneOf, v, path); n != 1 {
+			fail("matches %d of oneOf, want exactly 1", n)
+		}
+	}
+	if not, ok := sch["not"]; ok && s.countMatches([]any{not}, v, path) == 1 {
+		fail("matches a \"not\" schema")
+	}
+}
+
+// countMatches returns how many of schemas v satisfies
+func (s *jsonSchema) countMatches(schemas []any, v any, path string) int {
+	n := 0
+	for _, sub := range schemas {
+		var errs []string
+		s.validateNode(sub, v, path, &errs)
+		if len(errs) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func jsonTypeIs(v any, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return jsonTypeName(v) == name
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+func slicesContainsJSON(list []any, v any) bool {
+	for _, x := range list {
+		if reflect.DeepEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func compactJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestJSONSchemaValidate(t *testing.T) {
+	schema, err := parseJSONSchema([]byte(`{
+		"type": "object",
+		"required": ["maps"],
+		"properties": {
+			"maps": {"type": "array", "items": {"$ref": "#/$defs/map"}}
+		},
+		"$defs": {
+			"map": {
+				"type": "object",
+				"required": ["id"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "string", "pattern": "^[a-z]+$"},
+					"color": {"type": "integer", "minimum": 0},
+					"kind": {"oneOf": [{"const": "a"}, {"const": "b"}]}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := schema.validate([]byte(`{"maps": [
+		{"id": "ok", "color": 3, "kind": "a"},
+		{"id": "Bad", "color": 1.5, "extra": true},
+		{"color": -1, "kind": "c"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`/maps/1/color: got number, want integer`,
+		`/maps/1/extra: not allowed`,
+		`/maps/1/id: "Bad" does not match pattern "^[a-z]+$"`,
+		`/maps/2/color: -1 is below minimum 0`,
+		`/maps/2/kind: matches 0 of oneOf, want exactly 1`,
+		`/maps/2: missing required property "id"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("violations:\n%q\nwant:\n%q", got, want)
+	}
+
+	if got, _ := schema.validate([]byte(`{"maps": []}`)); len(got) != 0 {
+		t.Errorf("valid document: got %q", got)
+	}
+}
+
+func TestJSONSchemaRejectsUnsupported(t *testing.T) {
+	for _, bad := range []string{
+		`{"type": "object", "patternProperties": {}}`,
+		`{"$ref": "other.json#/x"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"pattern": "("}`,
+	} {
+		if _, err := parseJSONSchema([]byte(bad)); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestWriteMapsSchemaFailureLeavesFile(t *testing.T) {
+	schema, err := parseJSONSchema([]byte(`{"type": "array", "maxItems": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "maps.json")
+	if err := os.WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	maps := []OutputVideoMap{{ID: "m", Name: "M"}}
+	if _, err := writeMaps(path, maps, outputOptions{Schema: schema}); err == nil {
+		t.Fatal("expected a schema violation")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "previous" {
+		t.Errorf("target file = %q (%v), want it untouched", data, err)
+	}
+}
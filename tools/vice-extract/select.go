@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Map selection expressions (-select)
+//
+//   expr    = and { "or" and }
+//   and     = unary { "and" unary }
+//   unary   = "not" unary | "(" expr ")" | compare
+//   compare = field op value
+//   field   = "name" | "group" | "category" | "color" | "points"
+//   op      = "==" | "!=" | "<" | "<=" | ">" | ">=" | "matches"
+//   value   = integer | "double-quoted" | 'single-quoted'
+//
+// name takes a string with ==, != or matches (a Go regexp, unanchored);
+// the other fields take integers. points is the map's source point count.
+// Example: category == 2 and name matches "^JRV" and not color == 5
+// ──────────────────────────────────────────────────────────────────────
+
+// selectFields are the per-map values an expression can test
+type selectFields struct {
+	Name                           string
+	Group, Category, Color, Points int
+}
+
+func selectFieldsOf(vm VideoMap) selectFields {
+	return selectFields{
+		Name:     vm.Name,
+		Group:    vm.Group,
+		Category: vm.Category,
+		Color:    vm.Color,
+		Points:   countSourcePoints(vm),
+	}
+}
+
+// selectPredicate reports whether a map is selected
+type selectPredicate func(selectFields) bool
+
+// parseSelect compiles a -select expression
+func parseSelect(src string) (selectPredicate, error) {
+	toks, err := lexSelect(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &selectParser{toks: toks}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+	return pred, nil
+}
+
+type selectTokenKind int
+
+const (
+	tokEOF  selectTokenKind = iota
+	tokWord                 // field name or keyword
+	tokNumber
+	tokString
+	tokOp // comparison operator
+	tokLParen
+	tokRParen
+)
+
+type selectToken struct {
+	kind selectTokenKind
+	text string
+	pos  int
+}
+
+func (t selectToken) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lexSelect(src string) ([]selectToken, error) {
+	var toks []selectToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, selectToken{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, selectToken{tokRParen, ")", i})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(src) && src[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("invalid operator %q at offset %d (want == or !=)", op, i)
+			}
+			toks = append(toks, selectToken{tokOp, op, i})
+			i += len(op)
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			toks = append(toks, selectToken{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+				j++
+			}
+			toks = append(toks, selectToken{tokNumber, src[i:j], i})
+			i = j
+		case c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || (src[j]|0x20 >= 'a' && src[j]|0x20 <= 'z')) {
+				j++
+			}
+			word := src[i:j]
+			kind := tokWord
+			if word == "matches" {
+				kind = tokOp
+			}
+			toks = append(toks, selectToken{kind, word, i})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+	return append(toks, selectToken{tokEOF, "", len(src)}), nil
+}
+
+type selectParser struct {
+	toks []selectToken
+	i    int
+}
+
+func (p *selectParser) peek() selectToken { return p.toks[p.i] }
+
+func (p *selectParser) next() selectToken {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *selectParser) parseOr() (selectPredicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokWord && p.peek().text == "or" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f selectFields) bool { return l(f) || right(f) }
+	}
+	return left, nil
+}
+
+func (p *selectParser) parseAnd() (selectPredicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokWord && p.peek().text == "and" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f selectFields) bool { return l(f) && right(f) }
+	}
+	return left, nil
+}
+
+func (p *selectParser) parseUnary() (selectPredicate, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokWord && t.text == "not":
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(f selectFields) bool { return !inner(f) }, nil
+	case t.kind == tokLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at offset %d, got %s", t.pos, t)
+		}
+		return inner, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *selectParser) parseCompare() (selectPredicate, error) {
+	field := p.next()
+	if field.kind != tokWord {
+		return nil, fmt.Errorf("expected a field at offset %d, got %s", field.pos, field)
+	}
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, fmt.Errorf("expected an operator after %s at offset %d, got %s", field.text, op.pos, op)
+	}
+	value := p.next()
+
+	if field.text == "name" {
+		if value.kind != tokString {
+			return nil, fmt.Errorf("name compares against a quoted string (offset %d)", value.pos)
+		}
+		want := value.text
+		switch op.text {
+		case "==":
+			return func(f selectFields) bool { return f.Name == want }, nil
+		case "!=":
+			return func(f selectFields) bool { return f.Name != want }, nil
+		case "matches":
+			re, err := regexp.Compile(want)
+			if err != nil {
+				return nil, fmt.Errorf("bad regexp at offset %d: %w", value.pos, err)
+			}
+			return func(f selectFields) bool { return re.MatchString(f.Name) }, nil
+		}
+		return nil, fmt.Errorf("name supports ==, != and matches, not %s (offset %d)", op.text, op.pos)
+	}
+
+	var get func(selectFields) int
+	switch field.text {
+	case "group":
+		get = func(f selectFields) int { return f.Group }
+	case "category":
+		get = func(f selectFields) int { return f.Category }
+	case "color":
+		get = func(f selectFields) int { return f.Color }
+	case "points":
+		get = func(f selectFields) int { return f.Points }
+	default:
+		return nil, fmt.Errorf("unknown field %q at offset %d (want name, group, category, color, or points)", field.text, field.pos)
+	}
+	if value.kind != tokNumber {
+		return nil, fmt.Errorf("%s compares against an integer (offset %d)", field.text, value.pos)
+	}
+	n, err := strconv.Atoi(value.text)
+	if err != nil {
+		return nil, fmt.Errorf("bad integer %q at offset %d", value.text, value.pos)
+	}
+	switch op.text {
+	case "==":
+		return func(f selectFields) bool { return get(f) == n }, nil
+	case "!=":
+		return func(f selectFields) bool { return get(f) != n }, nil
+	case "<":
+		return func(f selectFields) bool { return get(f) < n }, nil
+	case "<=":
+		return func(f selectFields) bool { return get(f) <= n }, nil
+	case ">":
+		return func(f selectFields) bool { return get(f) > n }, nil
+	case ">=":
+		return func(f selectFields) bool { return get(f) >= n }, nil
+	}
+	return nil, fmt.Errorf("%s supports ==, !=, <, <=, >, >=, not %s (offset %d)", field.text, op.text, op.pos)
+}
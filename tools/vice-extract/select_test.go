@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestParseSelect(t *testing.T) {
+	maps := map[string]selectFields{
+		"jrv":   {Name: "JRV North", Group: 0, Category: 2, Color: 3, Points: 120},
+		"mva":   {Name: "PCT MVA", Group: 1, Category: 2, Color: 5, Points: 900},
+		"coast": {Name: "PCT Coastlines", Group: 1, Category: 0, Color: 1, Points: 5000},
+	}
+	tests := []struct {
+		expr string
+		want []string // selected keys of maps, in jrv, mva, coast order
+	}{
+		{`name == "PCT MVA"`, []string{"mva"}},
+		{`name != 'PCT MVA'`, []string{"jrv", "coast"}},
+		{`name matches "^PCT"`, []string{"mva", "coast"}},
+		{`category == 2 and name matches "JRV|MVA" and not color == 5`, []string{"jrv"}},
+		{`points > 500 and points < 1000`, []string{"mva"}},
+		{`points >= 900 or group <= 0`, []string{"jrv", "mva", "coast"}},
+		{`not (group == 1 or color == 3)`, nil},
+		{`color == 1 or color == 3 and category == 0`, []string{"coast"}}, // and binds tighter
+		{`(color == 1 or color == 3) and category == 2`, []string{"jrv"}},
+	}
+	for _, tt := range tests {
+		pred, err := parseSelect(tt.expr)
+		if err != nil {
+			t.Errorf("parseSelect(%q): %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, key := range []string{"jrv", "mva", "coast"} {
+			if pred(maps[key]) {
+				got = append(got, key)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q selected %v, want %v", tt.expr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q selected %v, want %v", tt.expr, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseSelectErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`name`,
+		`name = "x"`,
+		`name < "x"`,
+		`name == 3`,
+		`color == "red"`,
+		`colour == 1`,
+		`color matches "1"`,
+		`name matches "("`,
+		`(color == 1`,
+		`color == 1 color == 2`,
+		`name == "unterminated`,
+		`color == 1 and`,
+	} {
+		if _, err := parseSelect(expr); err == nil {
+			t.Errorf("parseSelect(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Line simplification (Ramer-Douglas-Peucker) in a flat nm projection
+// ──────────────────────────────────────────────────────────────────────
+
+// simplifyRDP drops points that lie within toleranceNM of the simplified
+// line. Endpoints are always kept, so closed rings stay closed.
+func simplifyRDP(strip []Point2LL, toleranceNM float64) []Point2LL {
+	if toleranceNM <= 0 || len(strip) <= 2 {
+		return strip
+	}
+
+	// Project to nm about the first point
+	lon0, lat0 := float64(strip[0][0]), float64(strip[0][1])
+	kx, ky := nmPerDegLon(lat0), nmPerDegLat(lat0)
+	xy := make([][2]float64, len(strip))
+	for i, p := range strip {
+		xy[i] = [2]float64{(float64(p[0]) - lon0) * kx, (float64(p[1]) - lat0) * ky}
+	}
+
+	keep := make([]bool, len(strip))
+	keep[0], keep[len(strip)-1] = true, true
+	type span struct{ first, last int }
+	stack := []span{{0, len(strip) - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		maxDist, maxIdx := 0.0, -1
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistance(xy[i], xy[s.first], xy[s.last]); d > maxDist {
+				maxDist, maxIdx = d, i
+			}
+		}
+		if maxIdx >= 0 && maxDist > toleranceNM {
+			keep[maxIdx] = true
+			stack = append(stack, span{s.first, maxIdx}, span{maxIdx, s.last})
+		}
+	}
+
+	out := make([]Point2LL, 0, len(strip))
+	for i, p := range strip {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// collapseCollinear drops interior points that lie within toleranceNM of
+// the straight segment between their kept neighbors. Every dropped point is
+// rechecked against the final chord, so gentle curves can't be flattened
+// a little at a time. Endpoints are always kept.
+func collapseCollinear(strip []Point2LL, toleranceNM float64) []Point2LL {
+	if len(strip) <= 2 {
+		return strip
+	}
+
+	// Project to nm about the first point
+	lon0, lat0 := float64(strip[0][0]), float64(strip[0][1])
+	kx, ky := nmPerDegLon(lat0), nmPerDegLat(lat0)
+	xy := make([][2]float64, len(strip))
+	for i, p := range strip {
+		xy[i] = [2]float64{(float64(p[0]) - lon0) * kx, (float64(p[1]) - lat0) * ky}
+	}
+
+	out := []Point2LL{strip[0]}
+	anchor := 0
+	for i := 1; i < len(strip)-1; i++ {
+		// Can every point from anchor+1 through i go, given chord anchor -> i+1?
+		collinear := true
+		for j := anchor + 1; j <= i; j++ {
+			if segmentDistance(xy[j], xy[anchor], xy[i+1]) > toleranceNM {
+				collinear = false
+				break
+			}
+		}
+		if !collinear {
+			out = append(out, strip[i])
+			anchor = i
+		}
+	}
+	return append(out, strip[len(strip)-1])
+}
+
+// maxDisplacementNM returns how far (nm) any point of orig lies from the
+// polyline through kept, which must be a subsequence of orig sharing its
+// endpoints (as simplifyRDP and collapseCollinear produce)
+func maxDisplacementNM(orig, kept []Point2LL) float64 {
+	if len(orig) <= 2 || len(kept) < 2 {
+		return 0
+	}
+	lon0, lat0 := float64(orig[0][0]), float64(orig[0][1])
+	kx, ky := nmPerDegLon(lat0), nmPerDegLat(lat0)
+	xy := func(p Point2LL) [2]float64 {
+		return [2]float64{(float64(p[0]) - lon0) * kx, (float64(p[1]) - lat0) * ky}
+	}
+
+	maxDist := 0.0
+	k := 0 // kept[k] .. kept[k+1] spans orig[i]
+	for i := 1; i < len(orig)-1; i++ {
+		if k+1 < len(kept)-1 && orig[i] == kept[k+1] {
+			k++
+			continue
+		}
+		maxDist = math.Max(maxDist, segmentDistance(xy(orig[i]), xy(kept[k]), xy(kept[k+1])))
+	}
+	return maxDist
+}
+
+// segmentDistance returns the distance from p to the segment a-b
+func segmentDistance(p, a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	lenSq := dx*dx + dy*dy
+	t := 0.0
+	if lenSq > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/lenSq))
+	}
+	ex, ey := p[0]-(a[0]+t*dx), p[1]-(a[1]+t*dy)
+	return math.Sqrt(ex*ex + ey*ey)
+}
+
+// budgetResult is the outcome of fitting maps under a total point budget
+type budgetResult struct {
+	Tolerance float64 // nm, applied to every map
+	Baseline  []int   // per-map points at the starting tolerance
+	Total     int     // total points at Tolerance
+}
+
+// findBudgetTolerance binary-searches the smallest RDP tolerance (at least
+// opts.SimplifyTolerance) that brings the converted maps to at most budget
+// points in total. Fails if even very coarse simplification can't get there
+// (RDP never drops a strip's endpoints).
+func findBudgetTolerance(maps []VideoMap, opts convertOptions, budget int) (budgetResult, error) {
+	count := func(tol float64) (int, []int) {
+		o := opts
+		o.SimplifyTolerance = tol
+		per := make([]int, len(maps))
+		total := 0
+		for i, vm := range maps {
+			m, _, _ := convertMapSafe(vm, false, o) // failures are reported by the main pass
+			per[i] = countPoints(m)
+			total += per[i]
+		}
+		return total, per
+	}
+
+	total, baseline := count(opts.SimplifyTolerance)
+	if total <= budget {
+		return budgetResult{Tolerance: opts.SimplifyTolerance, Baseline: baseline, Total: total}, nil
+	}
+	tol, ok := minFittingTolerance(opts.SimplifyTolerance, func(tol float64) bool {
+		t, _ := count(tol)
+		return t <= budget
+	})
+	if !ok {
+		t, _ := count(tol)
+		return budgetResult{}, fmt.Errorf("budget of %d points is unreachable: simplification bottoms out at %d points", budget, t)
+	}
+	total, _ = count(tol)
+	return budgetResult{Tolerance: tol, Baseline: baseline, Total: total}, nil
+}
+
+// minFittingTolerance finds (to about 1e-5 nm) the smallest RDP tolerance
+// above lo for which fits holds, assuming larger tolerances only help.
+// Returns the largest tolerance tried and false if none fits.
+func minFittingTolerance(lo float64, fits func(tol float64) bool) (float64, bool) {
+	// Grow an upper bound that fits
+	const maxTolerance = 1000.0 // nm; beyond this every strip is already 2 points
+	hi := math.Max(lo*2, 0.001)
+	for !fits(hi) {
+		if hi >= maxTolerance {
+			return hi, false
+		}
+		lo, hi = hi, hi*2
+	}
+
+	// Narrow to the smallest tolerance that still fits
+	for i := 0; i < 30 && hi-lo > 1e-5; i++ {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, true
+}
+
+// minSizePrecision is the coarsest precision -target-size-kb will choose
+// (1 decimal place ≈ 6 nm)
+const minSizePrecision = 1
+
+// sizeResult is the outcome of fitting the output under a size target
+type sizeResult struct {
+	Precision int
+	Tolerance float64 // nm
+	Bytes     int     // rendered size at Precision/Tolerance
+}
+
+// findTargetSize picks the highest coordinate precision (at most
+// opts.Precision) at which the rendered output fits in target bytes. If
+// even minSizePrecision doesn't fit and simplify is set, it then raises
+// the simplification tolerance at that precision. Sizes are measured by
+// converting and rendering every map, without -merge-into content.
+func findTargetSize(maps []VideoMap, opts convertOptions, oo outputOptions, target int, simplify bool) (sizeResult, error) {
+	oo.MergeBase = nil
+	var renderErr error
+	size := func(precision int, tol float64) int {
+		o := opts
+		o.Precision, o.SimplifyTolerance = precision, tol
+		out := make([]OutputVideoMap, len(maps))
+		for i, vm := range maps {
+			out[i], _, _ = convertMapSafe(vm, false, o)
+		}
+		ro := oo
+		ro.Decimals = precision // -no-scientific pads to the precision tried
+		data, err := renderMaps(out, ro)
+		if err != nil {
+			renderErr = err
+			return math.MaxInt
+		}
+		return len(data)
+	}
+	tol := opts.SimplifyTolerance
+	result := func(precision int, tol float64) (sizeResult, error) {
+		n := size(precision, tol)
+		return sizeResult{Precision: precision, Tolerance: tol, Bytes: n}, renderErr
+	}
+
+	hi := opts.Precision
+	if size(hi, tol) <= target {
+		return result(hi, tol)
+	}
+	lo := min(minSizePrecision, hi)
+	if size(lo, tol) <= target {
+		// Largest precision in [lo, hi) that fits; lo always does
+		for hi-lo > 1 {
+			mid := (lo + hi) / 2
+			if size(mid, tol) <= target {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		return result(lo, tol)
+	}
+	if renderErr != nil {
+		return sizeResult{}, renderErr
+	}
+	if !simplify {
+		return sizeResult{}, fmt.Errorf("target of %d bytes is unreachable by precision alone: %d bytes at precision %d (add -target-size-simplify)",
+			target, size(lo, tol), lo)
+	}
+	tol, ok := minFittingTolerance(tol, func(t float64) bool { return size(lo, t) <= target })
+	if !ok {
+		return sizeResult{}, fmt.Errorf("target of %d bytes is unreachable: %d bytes at precision %d with %.1f nm simplification",
+			target, size(lo, tol), lo, tol)
+	}
+	return result(lo, tol)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimplifyRDP(t *testing.T) {
+	// Straight run north with one 0.6 nm (0.01°) eastward kink in the middle
+	strip := []Point2LL{
+		{-77.00, 37.00},
+		{-77.00, 37.01},
+		{-76.99, 37.02}, // kink
+		{-77.00, 37.03},
+		{-77.00, 37.04},
+	}
+
+	if got := simplifyRDP(strip, 1.0); len(got) != 2 {
+		t.Errorf("tolerance 1.0 nm kept %d points, want 2 (kink below tolerance)", len(got))
+	}
+	got := simplifyRDP(strip, 0.3)
+	if len(got) != 3 || got[1] != strip[2] {
+		t.Errorf("tolerance 0.3 nm kept %v, want endpoints plus the kink", got)
+	}
+}
+
+func TestFindBudgetTolerance(t *testing.T) {
+	var strip []Point2LL
+	for i := 0; i < 50; i++ {
+		dx := float32(i%2) * 0.001 // small zigzag
+		strip = append(strip, Point2LL{-77.0 + dx, 37.0 + float32(i)*0.002})
+	}
+	maps := []VideoMap{{Name: "Zigzag", Lines: [][]Point2LL{strip}}}
+
+	res, err := findBudgetTolerance(maps, convertOptions{Precision: 5}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total > 10 {
+		t.Errorf("Total = %d, want <= 10", res.Total)
+	}
+	if res.Baseline[0] != 50 {
+		t.Errorf("Baseline = %v, want [50]", res.Baseline)
+	}
+	if res.Tolerance <= 0 {
+		t.Errorf("Tolerance = %v, want > 0", res.Tolerance)
+	}
+}
+
+func TestCollapseCollinear(t *testing.T) {
+	straight := []Point2LL{{-77, 37.00}, {-77, 37.01}, {-77, 37.02}, {-77, 37.03}, {-77, 37.04}}
+	if got := collapseCollinear(straight, 0.001); len(got) != 2 || got[0] != straight[0] || got[1] != straight[4] {
+		t.Errorf("straight run collapsed to %v, want its two endpoints", got)
+	}
+
+	// A right angle keeps its corner
+	corner := []Point2LL{{-77, 37.00}, {-77, 37.01}, {-77, 37.02}, {-76.99, 37.02}, {-76.98, 37.02}}
+	got := collapseCollinear(corner, 0.001)
+	if len(got) != 3 || got[1] != corner[2] {
+		t.Errorf("corner collapsed to %v, want endpoints plus the corner", got)
+	}
+
+	// A gentle curve: each point is near its neighbors' chord, but not the
+	// whole run's
+	var curve []Point2LL
+	for i := 0; i < 20; i++ {
+		x := float32(i) * 0.01
+		curve = append(curve, Point2LL{-77 + x, 37 + x*x*0.05})
+	}
+	if got := collapseCollinear(curve, 0.001); len(got) <= 2 {
+		t.Errorf("gentle curve collapsed to %d points, want its shape kept", len(got))
+	}
+}
+
+func TestFindTargetSize(t *testing.T) {
+	var strip []Point2LL
+	for i := 0; i < 200; i++ {
+		strip = append(strip, Point2LL{-77.123456 + float32(i)*0.00731, 37.654321 + float32(i%7)*0.00917})
+	}
+	maps := []VideoMap{{Name: "Zigzag", Lines: [][]Point2LL{strip}}}
+	opts := convertOptions{Precision: 6}
+	oo := outputOptions{Compact: true}
+
+	full, err := findTargetSize(maps, opts, oo, 1<<20, false)
+	if err != nil || full.Precision != 6 {
+		t.Fatalf("generous target: %+v, %v; want precision 6", full, err)
+	}
+
+	target := full.Bytes * 3 / 4
+	res, err := findTargetSize(maps, opts, oo, target, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Precision >= 6 || res.Bytes > target || res.Tolerance != 0 {
+		t.Errorf("3/4 target: %+v, want lower precision, no simplification, at most %d bytes", res, target)
+	}
+
+	if _, err := findTargetSize(maps, opts, oo, 200, false); err == nil {
+		t.Error("tiny target without simplification succeeded")
+	}
+	res, err = findTargetSize(maps, opts, oo, full.Bytes/10, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Precision != minSizePrecision || res.Tolerance <= 0 || res.Bytes > full.Bytes/10 {
+		t.Errorf("1/10 target with simplification: %+v", res)
+	}
+}
+
+func TestMaxDisplacementNM(t *testing.T) {
+	// Straight east-west line at the equator with one point 0.1° (6 nm) north
+	strip := []Point2LL{{0, 0}, {0.5, 0}, {1, 0.1}, {1.5, 0}, {2, 0}}
+	if d := maxDisplacementNM(strip, strip); d != 0 {
+		t.Errorf("unsimplified: %g, want 0", d)
+	}
+	kept := []Point2LL{strip[0], strip[4]}
+	if d := maxDisplacementNM(strip, kept); math.Abs(d-6) > 0.01 {
+		t.Errorf("endpoints only: %g nm, want ~6", d)
+	}
+	if d := maxDisplacementNM(strip, simplifyRDP(strip, 7)); math.Abs(d-6) > 0.01 {
+		t.Errorf("RDP at 7 nm: %g nm, want ~6 (the peak is dropped)", d)
+	}
+	// Keeps the peak; (0.5, 0) and (1.5, 0) sit ~2.985 nm off the new chords
+	if d := maxDisplacementNM(strip, simplifyRDP(strip, 5)); math.Abs(d-2.985) > 0.01 {
+		t.Errorf("RDP at 5 nm: %g nm, want ~2.985", d)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Delta output against a previous run (-since)
+// Each map's features are hashed in a whitespace- and key-order-
+// independent form and compared by map ID with the previous file. Only
+// new and changed maps are written; IDs that disappeared are listed in
+// the -wrap envelope's "removed". Metadata-only changes (name, color,
+// visibility, ...) don't count as changes.
+// ──────────────────────────────────────────────────────────────────────
+
+// featuresHash hashes a features value after normalizing it through a
+// generic JSON decode/encode (sorted keys, compact)
+func featuresHash(features any) ([32]byte, error) {
+	data, err := json.Marshal(features)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return [32]byte{}, err
+	}
+	if data, err = json.Marshal(v); err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// loadSinceHashes reads a previous atc-sim JSON output and returns each
+// map's feature hash by ID, plus the IDs in file order
+func loadSinceHashes(path string) (map[string][32]byte, []string, error) {
+	raw, err := loadMergeBase(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make(map[string][32]byte, len(raw))
+	var ids []string
+	for i, m := range raw {
+		var prev struct {
+			ID       string          `json:"id"`
+			Features json.RawMessage `json:"features"`
+		}
+		if err := json.Unmarshal(m, &prev); err != nil || prev.ID == "" {
+			return nil, nil, fmt.Errorf("%s: map %d has no \"id\"", path, i)
+		}
+		var features any
+		if len(prev.Features) > 0 {
+			if err := json.Unmarshal(prev.Features, &features); err != nil {
+				return nil, nil, fmt.Errorf("%s: map %s: %w", path, prev.ID, err)
+			}
+		}
+		h, err := featuresHash(features)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, dup := hashes[prev.ID]; !dup {
+			ids = append(ids, prev.ID)
+		}
+		hashes[prev.ID] = h
+	}
+	return hashes, ids, nil
+}
+
+// sinceDelta is the outcome of comparing converted maps with a previous run
+type sinceDelta struct {
+	Maps      []OutputVideoMap // new and changed maps, in output order
+	New       int
+	Changed   int
+	Unchanged int
+	Removed   []string // previous IDs with no converted map, in previous order
+}
+
+// diffSince keeps the maps whose features differ from (or are missing in)
+// the previous run
+func diffSince(maps []OutputVideoMap, prev map[string][32]byte, prevIDs []string) (sinceDelta, error) {
+	var d sinceDelta
+	current := make(map[string]bool, len(maps))
+	for _, m := range maps {
+		current[m.ID] = true
+		old, existed := prev[m.ID]
+		h, err := featuresHash(m.Features)
+		if err != nil {
+			return sinceDelta{}, fmt.Errorf("map %s: %w", m.ID, err)
+		}
+		switch {
+		case !existed:
+			d.New++
+		case h != old:
+			d.Changed++
+		default:
+			d.Unchanged++
+			continue
+		}
+		d.Maps = append(d.Maps, m)
+	}
+	for _, id := range prevIDs {
+		if !current[id] {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiffSince(t *testing.T) {
+	line := func(lat float64) []VideoMapFeature {
+		return []VideoMapFeature{{Type: "line", Points: []Position{{Lat: lat, Lon: -77}, {Lat: lat, Lon: -76}}}}
+	}
+	// Previous run, hand-reformatted (key order, whitespace) and with a
+	// metadata change that shouldn't count
+	prev := `{"metadata": {"generator": "vice-extract"}, "maps": [
+		{"id": "same", "name": "Old name", "features": [{"points": [{"lon": -77, "lat": 37}, {"lon": -76, "lat": 37}], "type": "line"}]},
+		{"id": "moved", "features": [{"type": "line", "points": [{"lat": 38, "lon": -77}, {"lat": 38, "lon": -76}]}]},
+		{"id": "gone", "features": []}
+	]}`
+	path := filepath.Join(t.TempDir(), "prev.json")
+	if err := os.WriteFile(path, []byte(prev), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hashes, ids, err := loadSinceHashes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maps := []OutputVideoMap{
+		{ID: "same", Name: "New name", Features: line(37)},
+		{ID: "moved", Features: line(38.5)},
+		{ID: "added", Features: line(39)},
+	}
+	d, err := diffSince(maps, hashes, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range d.Maps {
+		got = append(got, m.ID)
+	}
+	if !slices.Equal(got, []string{"moved", "added"}) || d.New != 1 || d.Changed != 1 || d.Unchanged != 1 {
+		t.Errorf("got maps %q (new %d, changed %d, unchanged %d); want [moved added] 1/1/1", got, d.New, d.Changed, d.Unchanged)
+	}
+	if !slices.Equal(d.Removed, []string{"gone"}) {
+		t.Errorf("Removed = %q, want [gone]", d.Removed)
+	}
+}
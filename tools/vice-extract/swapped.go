@@ -0,0 +1,74 @@
+package main
+
+import "slices"
+
+// ──────────────────────────────────────────────────────────────────────
+// Swapped lat/lon detection (-detect-swapped-coords, -repair-swapped)
+// A map whose Point2LLs were written [lat, lon] instead of [lon, lat]
+// lands far from everything else. A map is flagged when most of its points
+// are more than swappedPlausibleNM from the reference point but most
+// would be within it with the two values exchanged. The reference is the
+// first clip center, or else the median point of the whole library, which
+// a few swapped maps can't move far.
+// ──────────────────────────────────────────────────────────────────────
+
+// swappedPlausibleNM is how far from the reference a point may plausibly
+// be; a facility's maps span far less, and a swap moves points thousands
+// of nm
+const swappedPlausibleNM = 500
+
+// libraryMedian returns the per-axis median of every finite point
+func libraryMedian(maps []VideoMap) (Position, bool) {
+	var lats, lons []float64
+	for _, vm := range maps {
+		for _, strip := range vm.Lines {
+			for _, p := range strip {
+				if isFinite(p[0]) && isFinite(p[1]) {
+					lons = append(lons, float64(p[0]))
+					lats = append(lats, float64(p[1]))
+				}
+			}
+		}
+	}
+	if len(lats) == 0 {
+		return Position{}, false
+	}
+	slices.Sort(lats)
+	slices.Sort(lons)
+	return Position{Lat: lats[len(lats)/2], Lon: lons[len(lons)/2]}, true
+}
+
+// looksSwapped reports whether most of vm's points are implausibly far
+// from ref as stored but plausible with lat and lon exchanged
+func looksSwapped(vm VideoMap, ref Position) bool {
+	total, near, nearSwapped := 0, 0, 0
+	for _, strip := range vm.Lines {
+		for _, p := range strip {
+			if !isFinite(p[0]) || !isFinite(p[1]) {
+				continue
+			}
+			total++
+			lon, lat := float64(p[0]), float64(p[1])
+			if distanceNM(ref.Lat, ref.Lon, lat, lon) <= swappedPlausibleNM {
+				near++
+			}
+			if distanceNM(ref.Lat, ref.Lon, lon, lat) <= swappedPlausibleNM {
+				nearSwapped++
+			}
+		}
+	}
+	return total > 0 && 2*near < total && 2*nearSwapped > total
+}
+
+// swapLatLon returns a copy of vm with each point's values exchanged
+func swapLatLon(vm VideoMap) VideoMap {
+	lines := make([][]Point2LL, len(vm.Lines))
+	for i, strip := range vm.Lines {
+		lines[i] = make([]Point2LL, len(strip))
+		for j, p := range strip {
+			lines[i][j] = Point2LL{p[1], p[0]}
+		}
+	}
+	vm.Lines = lines
+	return vm
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestLooksSwapped(t *testing.T) {
+	good := VideoMap{Name: "Good", Lines: [][]Point2LL{{{-77.4, 37.5}, {-77.3, 37.6}, {-77.2, 37.5}}}}
+	other := VideoMap{Name: "Other", Lines: [][]Point2LL{{{-77.0, 37.0}, {-76.9, 37.1}}}}
+	swapped := swapLatLon(VideoMap{Name: "Swapped", Lines: [][]Point2LL{{{-77.5, 37.4}, {-77.4, 37.4}, {-77.4, 37.3}}}})
+	maps := []VideoMap{good, other, swapped}
+
+	ref, ok := libraryMedian(maps)
+	if !ok {
+		t.Fatal("libraryMedian: no points")
+	}
+	for _, vm := range maps {
+		if got, want := looksSwapped(vm, ref), vm.Name == "Swapped"; got != want {
+			t.Errorf("%s: looksSwapped = %v, want %v", vm.Name, got, want)
+		}
+	}
+
+	repaired := swapLatLon(swapped)
+	if p := repaired.Lines[0][0]; p != (Point2LL{-77.5, 37.4}) {
+		t.Errorf("repaired first point = %v, want [-77.5 37.4]", p)
+	}
+	if looksSwapped(repaired, ref) {
+		t.Error("repaired map still looks swapped")
+	}
+	if swapped.Lines[0][0] != (Point2LL{37.4, -77.5}) {
+		t.Error("swapLatLon modified its input")
+	}
+}
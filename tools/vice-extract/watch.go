@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Watch mode (-watch)
+// Each extraction runs as a child process (same arguments, watch disabled
+// via watchChildEnv) so a failed run — main exits on errors — doesn't take
+// the watcher down with it. Inputs are polled; no fsnotify dependency.
+// ──────────────────────────────────────────────────────────────────────
+
+const watchChildEnv = "VICE_EXTRACT_WATCH_CHILD"
+
+// fileStamp is what polling compares to notice a change
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, p := range paths {
+		if fi, err := os.Stat(p); err == nil {
+			stamps[p] = fileStamp{modTime: fi.ModTime(), size: fi.Size(), exists: true}
+		} else {
+			stamps[p] = fileStamp{}
+		}
+	}
+	return stamps
+}
+
+// changedFile returns the first path whose stamp differs, or ""
+func changedFile(paths []string, before, after map[string]fileStamp) string {
+	for _, p := range paths {
+		if before[p] != after[p] {
+			return p
+		}
+	}
+	return ""
+}
+
+// runWatch extracts once, then re-extracts whenever one of paths changes.
+// A change is acted on once the files have been stable for one interval,
+// so a half-written input isn't read. Runs until interrupted.
+func runWatch(paths []string, interval time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+	extract := func() {
+		start := time.Now()
+		cmd := exec.Command(exe, os.Args[1:]...)
+		cmd.Env = append(os.Environ(), watchChildEnv+"=1")
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		status := "done"
+		if err := cmd.Run(); err != nil {
+			status = "FAILED (" + err.Error() + ")"
+		}
+		fmt.Fprintf(stderr, "[%s] Extraction %s in %.1fs; watching %d files\n",
+			time.Now().Format("15:04:05"), status, time.Since(start).Seconds(), len(paths))
+	}
+
+	stamps := statFiles(paths)
+	extract()
+	for {
+		time.Sleep(interval)
+		now := statFiles(paths)
+		changed := changedFile(paths, stamps, now)
+		if changed == "" {
+			continue
+		}
+		// Wait for writes to settle
+		for {
+			stamps = now
+			time.Sleep(interval)
+			now = statFiles(paths)
+			if changedFile(paths, stamps, now) == "" {
+				break
+			}
+		}
+		fmt.Fprintf(stderr, "\n[%s] %s changed, re-extracting\n", time.Now().Format("15:04:05"), changed)
+		extract()
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// WKT output (-format wkt)
+// One line per map: id<TAB>geometry, e.g. for loading into PostGIS with
+// ST_GeomFromText. Coordinates are "lon lat" as WKT expects. Arc features
+// have no point list and are left out.
+// ──────────────────────────────────────────────────────────────────────
+
+// formatWKT renders each map as a MULTILINESTRING. With detectPolygons,
+// closed strips (ends within closeEpsNM) become polygons: MULTIPOLYGON if
+// every strip is closed, otherwise a GEOMETRYCOLLECTION of POLYGONs and
+// LINESTRINGs. A polygon's last point is snapped to its first, since WKT
+// rings must close exactly.
+func formatWKT(maps []OutputVideoMap, detectPolygons bool, closeEpsNM float64) []byte {
+	var b bytes.Buffer
+	for _, m := range maps {
+		var strips [][]Position
+		allClosed := true
+		for _, f := range m.Features {
+			if len(f.Points) < 2 {
+				continue
+			}
+			strips = append(strips, f.Points)
+			allClosed = allClosed && isClosedPositions(f.Points, closeEpsNM)
+		}
+
+		b.WriteString(m.ID)
+		b.WriteByte('\t')
+		switch {
+		case len(strips) == 0:
+			b.WriteString("MULTILINESTRING EMPTY")
+		case !detectPolygons:
+			b.WriteString("MULTILINESTRING(")
+			writeWKTStrips(&b, strips, "(", ")")
+			b.WriteByte(')')
+		case allClosed:
+			for i, s := range strips {
+				strips[i] = closeRing(s)
+			}
+			b.WriteString("MULTIPOLYGON(")
+			writeWKTStrips(&b, strips, "((", "))")
+			b.WriteByte(')')
+		default:
+			b.WriteString("GEOMETRYCOLLECTION(")
+			for i, s := range strips {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				if isClosedPositions(s, closeEpsNM) {
+					b.WriteString("POLYGON")
+					writeWKTStrips(&b, [][]Position{closeRing(s)}, "((", "))")
+				} else {
+					b.WriteString("LINESTRING")
+					writeWKTStrips(&b, [][]Position{s}, "(", ")")
+				}
+			}
+			b.WriteByte(')')
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// writeWKTStrips writes comma-separated coordinate lists, each between
+// open and close
+func writeWKTStrips(b *bytes.Buffer, strips [][]Position, open, close string) {
+	for i, s := range strips {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(open)
+		for j, p := range s {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			// Points are already rounded to -precision; 'f', -1 adds no padding
+			b.WriteString(strconv.FormatFloat(p.Lon, 'f', -1, 64))
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(p.Lat, 'f', -1, 64))
+		}
+		b.WriteString(close)
+	}
+}
+
+// isClosedPositions reports whether a strip ends within epsNM of where it
+// starts and has enough points to be a ring
+func isClosedPositions(s []Position, epsNM float64) bool {
+	return len(s) >= 4 && samePoint(s[0].Lat, s[0].Lon, s[len(s)-1].Lat, s[len(s)-1].Lon, epsNM)
+}
+
+// closeRing returns s with its last point replaced by its first (s itself
+// if already exact)
+func closeRing(s []Position) []Position {
+	if s[0] == s[len(s)-1] {
+		return s
+	}
+	return append(slices.Clone(s[:len(s)-1]), s[0])
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestFormatWKT(t *testing.T) {
+	line := []Position{{Lat: 37.5, Lon: -77.3}, {Lat: 37.51, Lon: -77.25}}
+	ring := []Position{{Lat: 37, Lon: -77}, {Lat: 37, Lon: -76.9}, {Lat: 37.1, Lon: -76.9}, {Lat: 37, Lon: -77}}
+	maps := []OutputVideoMap{
+		{ID: "mixed", Features: []VideoMapFeature{{Type: "line", Points: line}, {Type: "line", Points: ring}}},
+		{ID: "rings", Features: []VideoMapFeature{{Type: "line", Points: ring}}},
+		{ID: "empty"},
+	}
+
+	want := "mixed\tMULTILINESTRING((-77.3 37.5, -77.25 37.51), (-77 37, -76.9 37, -76.9 37.1, -77 37))\n" +
+		"rings\tMULTILINESTRING((-77 37, -76.9 37, -76.9 37.1, -77 37))\n" +
+		"empty\tMULTILINESTRING EMPTY\n"
+	if got := string(formatWKT(maps, false, 0)); got != want {
+		t.Errorf("lines:\ngot  %q\nwant %q", got, want)
+	}
+
+	want = "mixed\tGEOMETRYCOLLECTION(LINESTRING(-77.3 37.5, -77.25 37.51), POLYGON((-77 37, -76.9 37, -76.9 37.1, -77 37)))\n" +
+		"rings\tMULTIPOLYGON(((-77 37, -76.9 37, -76.9 37.1, -77 37)))\n" +
+		"empty\tMULTILINESTRING EMPTY\n"
+	if got := string(formatWKT(maps, true, 0)); got != want {
+		t.Errorf("polygons:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestFormatWKTCoordinateEpsilon(t *testing.T) {
+	// The ring ends ~0.006 nm (about 11 m) short of its start
+	nearRing := []Position{{Lat: 37, Lon: -77}, {Lat: 37, Lon: -76.9}, {Lat: 37.1, Lon: -76.9}, {Lat: 37.0001, Lon: -77}}
+	maps := []OutputVideoMap{{ID: "r", Features: []VideoMapFeature{{Type: "line", Points: nearRing}}}}
+
+	if got, want := string(formatWKT(maps, true, 0)), "r\tGEOMETRYCOLLECTION(LINESTRING(-77 37, -76.9 37, -76.9 37.1, -77 37.0001))\n"; got != want {
+		t.Errorf("epsilon 0:\ngot  %q\nwant %q", got, want)
+	}
+	// Within epsilon: a polygon, closed exactly on the first point
+	if got, want := string(formatWKT(maps, true, 0.01)), "r\tMULTIPOLYGON(((-77 37, -76.9 37, -76.9 37.1, -77 37)))\n"; got != want {
+		t.Errorf("epsilon 0.01:\ngot  %q\nwant %q", got, want)
+	}
+	if nearRing[3].Lat != 37.0001 {
+		t.Error("closeRing modified the input")
+	}
+}